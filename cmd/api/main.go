@@ -1,16 +1,19 @@
 package main
 
 import (
+	"avito-assignment/internal/api/grpcapi"
 	"avito-assignment/internal/api/handlers"
+	"avito-assignment/internal/api/openapi"
 	"avito-assignment/internal/config"
 	"avito-assignment/internal/db"
 	"avito-assignment/internal/repository"
 	"avito-assignment/internal/service"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"time"
 
-	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
 )
 
@@ -31,47 +34,72 @@ func main() {
 	teamRepo := repository.NewTeamRepository(dbConn)
 	prRepo := repository.NewPRRepository(dbConn)
 	statsRepo := repository.NewStatisticsRepository(dbConn)
+	eventRepo := repository.NewEventRepository(dbConn)
+	repoRepo := repository.NewRepositoryRepository(dbConn)
+	conflictRepo := repository.NewConflictRepository(dbConn)
+	idempotencyRepo := repository.NewIdempotencyRepository(dbConn)
+	snapshotRepo := repository.NewSnapshotRepository(dbConn)
 
 	// Инициализация сервисов
-	userService := service.NewUserService(userRepo)
+	prService := service.NewPRService(prRepo, userRepo, teamRepo, eventRepo, repoRepo, conflictRepo, cfg.Review.MaxOpenReviews)
+	userService := service.NewUserService(userRepo, prService)
 	teamService := service.NewTeamService(teamRepo, userRepo)
-	prService := service.NewPRService(prRepo, userRepo, teamRepo)
 	statsService := service.NewStatisticsService(statsRepo)
+	repositoryService := service.NewRepositoryService(repoRepo, teamRepo)
+	conflictService := service.NewConflictService(conflictRepo, userRepo)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.Idempotency.TTL)
+	snapshotService := service.NewSnapshotService(snapshotRepo)
+
+	// Фоновая очистка истекших ключей идемпотентности
+	go idempotencyService.RunCleanup(time.Hour)
 
 	// Инициализация HTTP обработчиков
 	userHandler := &handlers.UserHandler{Service: userService}
 	teamHandler := &handlers.TeamHandler{Service: teamService, PRService: prService}
 	prHandler := &handlers.PRHandler{Service: prService}
 	statsHandler := &handlers.StatisticsHandler{Service: statsService}
+	repositoryHandler := &handlers.RepositoryHandler{Service: repositoryService}
+	conflictHandler := &handlers.ConflictHandler{Service: conflictService}
+	snapshotHandler := &handlers.SnapshotHandler{Service: snapshotService}
+	idempotency := &handlers.Idempotency{Service: idempotencyService}
 
-	r := mux.NewRouter()
-
-	// Health check endpoint - проверка работоспособности сервиса
-	r.HandleFunc("/health", handlers.HealthCheck).Methods("GET")
-
-	// User endpoints - управление пользователями
-	r.HandleFunc("/api/v1/users", userHandler.CreateUser).Methods("POST")
-	r.HandleFunc("/api/v1/users/{user_id}", userHandler.GetUser).Methods("GET")
-	r.HandleFunc("/api/v1/users/{user_id}", userHandler.UpdateUser).Methods("PUT")
-	r.HandleFunc("/api/v1/users/{user_id}", userHandler.DeleteUser).Methods("DELETE")
-	r.HandleFunc("/api/v1/users/{user_id}/pull-requests", userHandler.GetUserPRs).Methods("GET")
-
-	// Team endpoints - управление командами
-	r.HandleFunc("/api/v1/team/add", teamHandler.CreateTeam).Methods("POST")
-	r.HandleFunc("/api/v1/team/{team_id}", teamHandler.GetTeam).Methods("GET")
-	r.HandleFunc("/api/v1/team/{team_id}", teamHandler.UpdateTeam).Methods("PUT")
-	r.HandleFunc("/api/v1/team/{team_id}", teamHandler.DeleteTeam).Methods("DELETE")
-	r.HandleFunc("/api/v1/team/{team_id}/deactivate-members", teamHandler.DeactivateTeamMembers).Methods("POST")
+	spec, err := openapi.Load()
+	if err != nil {
+		log.Fatalf("Failed to load OpenAPI spec: %v", err)
+	}
 
-	// PR endpoints - управление Pull Requests
-	r.HandleFunc("/api/v1/pull-request/create", prHandler.CreatePR).Methods("POST")
-	r.HandleFunc("/api/v1/pull-request", prHandler.GetAllPRs).Methods("GET")
-	r.HandleFunc("/api/v1/pull-request/{pull_request_id}", prHandler.GetPR).Methods("GET")
-	r.HandleFunc("/api/v1/pull-request/reassign", prHandler.ReassignReviewer).Methods("POST")
-	r.HandleFunc("/api/v1/pull-request/merge", prHandler.MergePR).Methods("POST")
+	r := newRouter(routeHandlers{
+		user:        userHandler,
+		team:        teamHandler,
+		pr:          prHandler,
+		stats:       statsHandler,
+		repository:  repositoryHandler,
+		conflict:    conflictHandler,
+		snapshot:    snapshotHandler,
+		idempotency: idempotency,
+	}, spec)
 
-	// Statistics endpoint - статистика по назначениям
-	r.HandleFunc("/api/v1/statistics", statsHandler.GetStatistics).Methods("GET")
+	// Запуск gRPC сервера на отдельном порту; он использует те же сервисы, что и REST API
+	grpcServer := grpcapi.NewServer(grpcapi.Servers{
+		User:       &grpcapi.UserServer{Service: userService},
+		Team:       &grpcapi.TeamServer{Service: teamService, PRService: prService},
+		PR:         &grpcapi.PullRequestServer{Service: prService},
+		Statistics: &grpcapi.StatisticsServer{Service: statsService},
+	})
+	grpcAddr := ":9090"
+	if port := os.Getenv("GRPC_PORT"); port != "" {
+		grpcAddr = ":" + port
+	}
+	grpcListener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", grpcAddr, err)
+	}
+	go func() {
+		log.Printf("Starting gRPC server at %s", grpcAddr)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatalf("gRPC server failed: %v", err)
+		}
+	}()
 
 	// Запуск HTTP сервера
 	addr := ":8080"
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"avito-assignment/internal/api/handlers"
+	"avito-assignment/internal/api/openapi"
+
+	"github.com/gorilla/mux"
+)
+
+// routeHandlers объединяет HTTP обработчики, из которых собирается роутер.
+type routeHandlers struct {
+	user       *handlers.UserHandler
+	team       *handlers.TeamHandler
+	pr         *handlers.PRHandler
+	stats      *handlers.StatisticsHandler
+	repository *handlers.RepositoryHandler
+	conflict   *handlers.ConflictHandler
+	snapshot   *handlers.SnapshotHandler
+	// idempotency применяется к мутирующим маршрутам, повтор которых создал бы дубликат
+	idempotency *handlers.Idempotency
+}
+
+// newRouter регистрирует все маршруты API. Каждый маршрут должен быть описан
+// в OpenAPI-спецификации: по ней проверяются запросы (см. routes_test.go).
+func newRouter(h routeHandlers, spec *openapi.Spec) *mux.Router {
+	r := mux.NewRouter()
+	r.Use(spec.ValidationMiddleware)
+
+	// Documentation endpoints - OpenAPI-спецификация и Swagger UI
+	r.HandleFunc("/openapi.json", openapi.ServeSpec).Methods("GET")
+	r.HandleFunc("/docs", openapi.ServeDocs).Methods("GET")
+	r.HandleFunc("/docs/swagger-ui/{file}", openapi.ServeDocsAsset).Methods("GET")
+
+	// Health check endpoint - проверка работоспособности сервиса
+	r.HandleFunc("/health", handlers.HealthCheck).Methods("GET")
+
+	// User endpoints - управление пользователями
+	r.HandleFunc("/api/v1/users", h.idempotency.Wrap(h.user.CreateUser)).Methods("POST")
+	r.HandleFunc("/api/v1/users", h.user.ListUsers).Methods("GET")
+	r.HandleFunc("/api/v1/users/{user_id}", h.user.GetUser).Methods("GET")
+	r.HandleFunc("/api/v1/users/{user_id}", h.user.UpdateUser).Methods("PUT")
+	r.HandleFunc("/api/v1/users/{user_id}", h.user.PatchUser).Methods("PATCH")
+	r.HandleFunc("/api/v1/users/{user_id}", h.user.DeleteUser).Methods("DELETE")
+	r.HandleFunc("/api/v1/users/{user_id}/pull-requests", h.user.GetUserPRs).Methods("GET")
+	r.HandleFunc("/api/v1/users/{user_id}/review-queue", h.user.GetReviewQueue).Methods("GET")
+
+	// Team endpoints - управление командами
+	r.HandleFunc("/api/v1/team", h.idempotency.Wrap(h.team.CreateTeam)).Methods("POST")
+	r.HandleFunc("/api/v1/team", h.team.ListTeams).Methods("GET")
+	// Регистрируется раньше /team/{team_id}, иначе PUT /team/upsert попал бы в UpdateTeam
+	r.HandleFunc("/api/v1/team/upsert", h.team.UpsertTeam).Methods("PUT")
+	// Старый путь создания команды сохранен для совместимости
+	r.HandleFunc("/api/v1/team/add", h.idempotency.Wrap(h.team.CreateTeam)).Methods("POST")
+	r.HandleFunc("/api/v1/team/{team_id}", h.team.GetTeam).Methods("GET")
+	r.HandleFunc("/api/v1/team/{team_id}", h.team.UpdateTeam).Methods("PUT")
+	r.HandleFunc("/api/v1/team/{team_id}", h.team.PatchTeam).Methods("PATCH")
+	r.HandleFunc("/api/v1/team/{team_id}", h.team.DeleteTeam).Methods("DELETE")
+	r.HandleFunc("/api/v1/team/{team_id}/deactivate-members", h.team.DeactivateTeamMembers).Methods("POST")
+	r.HandleFunc("/api/v1/team/{team_id}/review-pool", h.team.GetReviewPool).Methods("GET")
+	r.HandleFunc("/api/v1/team/{team_id}/shadowing", h.team.UpdateShadowing).Methods("PUT")
+	r.HandleFunc("/api/v1/team/{team_id}/reviewer-tiers", h.team.UpdateReviewerTiers).Methods("PUT")
+	r.HandleFunc("/api/v1/team/{team_id}/review-policy", h.team.UpdateReviewPolicy).Methods("PUT")
+
+	// Repository endpoints - управление репозиториями
+	r.HandleFunc("/api/v1/repositories", h.repository.CreateRepository).Methods("POST")
+	r.HandleFunc("/api/v1/repositories", h.repository.GetRepositories).Methods("GET")
+	r.HandleFunc("/api/v1/repositories/{repository_id}", h.repository.GetRepository).Methods("GET")
+	r.HandleFunc("/api/v1/repositories/{repository_id}", h.repository.UpdateRepository).Methods("PUT")
+	r.HandleFunc("/api/v1/repositories/{repository_id}", h.repository.DeleteRepository).Methods("DELETE")
+
+	// PR endpoints - управление Pull Requests
+	r.HandleFunc("/api/v1/pull-request/create", h.idempotency.Wrap(h.pr.CreatePR)).Methods("POST")
+	r.HandleFunc("/api/v1/pull-request", h.pr.ListPRs).Methods("GET")
+	r.HandleFunc("/api/v1/pull-request/{pull_request_id}", h.pr.GetPR).Methods("GET")
+	r.HandleFunc("/api/v1/pull-request/reassign", h.idempotency.Wrap(h.pr.ReassignReviewer)).Methods("POST")
+	r.HandleFunc("/api/v1/pull-request/merge", h.pr.MergePR).Methods("POST")
+	r.HandleFunc("/api/v1/pull-request/{pull_request_id}/dependencies", h.pr.AddDependencies).Methods("POST")
+	r.HandleFunc("/api/v1/pull-request/{pull_request_id}/events", h.pr.GetPREvents).Methods("GET")
+	r.HandleFunc("/api/v1/pull-request/{pull_request_id}/decline", h.pr.DeclineReview).Methods("POST")
+	r.HandleFunc("/api/v1/pull-request/{pull_request_id}/claim", h.pr.ClaimReview).Methods("POST")
+	r.HandleFunc("/api/v1/pull-request/{pull_request_id}/optional-reviewers", h.pr.AddOptionalReviewers).Methods("POST")
+	r.HandleFunc("/api/v1/pull-request/{pull_request_id}/approve", h.pr.ApproveReview).Methods("POST")
+	r.HandleFunc("/api/v1/pull-request/{pull_request_id}/revisions", h.pr.PushRevision).Methods("POST")
+
+	// Conflict-of-interest endpoints - правила конфликта интересов (администрирование)
+	r.HandleFunc("/api/v1/admin/conflicts", h.conflict.CreateConflict).Methods("POST")
+	r.HandleFunc("/api/v1/admin/conflicts", h.conflict.GetConflicts).Methods("GET")
+	r.HandleFunc("/api/v1/admin/conflicts/{conflict_id}", h.conflict.DeleteConflict).Methods("DELETE")
+
+	// Snapshot endpoints - выгрузка и загрузка данных (администрирование)
+	r.HandleFunc("/api/v1/admin/export", h.snapshot.Export).Methods("GET")
+	r.HandleFunc("/api/v1/admin/import", h.snapshot.Import).Methods("POST")
+
+	// Statistics endpoint - статистика по назначениям
+	r.HandleFunc("/api/v1/statistics", h.stats.GetStatistics).Methods("GET")
+
+	return r
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"avito-assignment/internal/api/openapi"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// TestEveryRouteHasSpecEntry падает, если в роутере есть маршрут без операции в OpenAPI-спецификации.
+func TestEveryRouteHasSpecEntry(t *testing.T) {
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	r := newRouter(routeHandlers{}, spec)
+
+	checked := 0
+	err = r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		tmpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			t.Errorf("route %s has no methods", tmpl)
+			return nil
+		}
+		for _, method := range methods {
+			checked++
+			if spec.Operation(tmpl, method) == nil {
+				t.Errorf("route %s %s is missing from openapi.json", method, tmpl)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+	if checked == 0 {
+		t.Fatal("router has no routes")
+	}
+}
+
+// TestValidationMiddlewareRejectsInvalidRequests проверяет, что невалидные запросы
+// отклоняются до обработчиков (обработчики здесь без сервисов и упали бы).
+func TestValidationMiddlewareRejectsInvalidRequests(t *testing.T) {
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	r := newRouter(routeHandlers{}, spec)
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"invalid path UUID", http.MethodGet, "/api/v1/users/not-a-uuid", ""},
+		{"missing required field", http.MethodPost, "/api/v1/pull-request/merge", `{}`},
+		{"wrong field type", http.MethodPost, "/api/v1/pull-request/create",
+			`{"pull_request_name": "x", "author_id": "6f1c1a52-0d7b-4c59-9f0a-3c0f8f0f6b11",
+			  "repository_id": "6f1c1a52-0d7b-4c59-9f0a-3c0f8f0f6b11", "lines_added": "many"}`},
+		{"missing repository", http.MethodPost, "/api/v1/pull-request/create",
+			`{"pull_request_name": "x", "author_id": "6f1c1a52-0d7b-4c59-9f0a-3c0f8f0f6b11"}`},
+		{"invalid enum", http.MethodPut, "/api/v1/repositories/6f1c1a52-0d7b-4c59-9f0a-3c0f8f0f6b11",
+			`{"assignment_strategy": "round_robin"}`},
+		{"malformed JSON", http.MethodPost, "/api/v1/admin/conflicts", `{`},
+		{"team upsert without members", http.MethodPut, "/api/v1/team/upsert", `{"team_name": "backend"}`},
+		{"query enum", http.MethodGet, "/api/v1/pull-request?status=CLOSED", ""},
+		{"query integer range", http.MethodGet, "/api/v1/pull-request?limit=500", ""},
+		{"query date-time", http.MethodGet, "/api/v1/pull-request?created_from=yesterday", ""},
+		{"export format", http.MethodGet, "/api/v1/admin/export?format=xml", ""},
+		{"import conflict policy", http.MethodPost, "/api/v1/admin/import?on_conflict=merge", "{}"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), "VALIDATION_ERROR") {
+				t.Fatalf("expected VALIDATION_ERROR, got %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+// TestConditionalRoutesRequireIfMatch проверяет, что изменения с оптимистичной блокировкой
+// без заголовка If-Match отклоняются с 428 до обращения к сервисам.
+func TestConditionalRoutesRequireIfMatch(t *testing.T) {
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	r := newRouter(routeHandlers{}, spec)
+
+	const id = "6f1c1a52-0d7b-4c59-9f0a-3c0f8f0f6b11"
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"update user", http.MethodPut, "/api/v1/users/" + id,
+			`{"user_id": "` + id + `", "username": "alice", "team_id": "` + id + `", "is_active": true}`},
+		{"update team", http.MethodPut, "/api/v1/team/" + id, `{"team_name": "backend"}`},
+		{"patch user", http.MethodPatch, "/api/v1/users/" + id, `{"is_senior": true}`},
+		{"patch team", http.MethodPatch, "/api/v1/team/" + id, `{"team_name": "backend"}`},
+		{"upsert team", http.MethodPut, "/api/v1/team/upsert", `{"team_name": "backend", "members": []}`},
+		{"update shadowing", http.MethodPut, "/api/v1/team/" + id + "/shadowing", `{"shadowing_enabled": true}`},
+		{"update reviewer tiers", http.MethodPut, "/api/v1/team/" + id + "/reviewer-tiers", `{"reviewer_tiers": []}`},
+		{"update review policy", http.MethodPut, "/api/v1/team/" + id + "/review-policy",
+			`{"reset_approvals_on_revision": true}`},
+		{"merge", http.MethodPost, "/api/v1/pull-request/merge", `{"pull_request_id": "` + id + `"}`},
+		{"reassign", http.MethodPost, "/api/v1/pull-request/reassign",
+			`{"pull_request_id": "` + id + `", "old_user_id": "` + id + `"}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != http.StatusPreconditionRequired {
+				t.Fatalf("expected 428, got %d: %s", rec.Code, rec.Body.String())
+			}
+
+			req = httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("If-Match", "3")
+			rec = httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400 for unquoted If-Match, got %d: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Package apierror описывает единый формат ответа HTTP API с ошибкой. Его используют
+// и обработчики, и middleware проверки запросов по спецификации.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Response — единый формат ответа с ошибкой:
+// {"error": {"code": "...", "message": "...", "details": {...}}}.
+type Response struct {
+	Error Body `json:"error"`
+}
+
+// Body описывает ошибку: машиночитаемый код, сообщение и подробности (пустой объект, если их нет).
+type Body struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details"`
+}
+
+// Write отвечает ошибкой с указанным статусом.
+func Write(w http.ResponseWriter, status int, code, message string, details interface{}) {
+	if details == nil {
+		details = struct{}{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(Response{Error: Body{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}})
+	if err != nil {
+		return
+	}
+}
+
+// WriteValidation отвечает 400 VALIDATION_ERROR на некорректные параметры или тело запроса.
+func WriteValidation(w http.ResponseWriter, message string) {
+	Write(w, http.StatusBadRequest, "VALIDATION_ERROR", message, nil)
+}
@@ -0,0 +1,293 @@
+package grpcapi
+
+import (
+	"avito-assignment/internal/api/grpcapi/reviewerpb"
+	"avito-assignment/internal/model"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var statusToProto = map[model.PRStatus]reviewerpb.PullRequestStatus{
+	model.OPEN:   reviewerpb.PullRequestStatus_PULL_REQUEST_STATUS_OPEN,
+	model.MERGED: reviewerpb.PullRequestStatus_PULL_REQUEST_STATUS_MERGED,
+}
+
+var priorityToProto = map[model.PRPriority]reviewerpb.Priority{
+	model.PriorityLow:      reviewerpb.Priority_PRIORITY_LOW,
+	model.PriorityNormal:   reviewerpb.Priority_PRIORITY_NORMAL,
+	model.PriorityHigh:     reviewerpb.Priority_PRIORITY_HIGH,
+	model.PriorityCritical: reviewerpb.Priority_PRIORITY_CRITICAL,
+}
+
+var roleToProto = map[model.ReviewerRole]reviewerpb.ReviewerRole{
+	model.RoleRequired: reviewerpb.ReviewerRole_REVIEWER_ROLE_REQUIRED,
+	model.RoleOptional: reviewerpb.ReviewerRole_REVIEWER_ROLE_OPTIONAL,
+	model.RoleShadow:   reviewerpb.ReviewerRole_REVIEWER_ROLE_SHADOW,
+}
+
+var decisionToProto = map[model.ReviewDecision]reviewerpb.ReviewDecision{
+	model.DecisionPending:  reviewerpb.ReviewDecision_REVIEW_DECISION_PENDING,
+	model.DecisionApproved: reviewerpb.ReviewDecision_REVIEW_DECISION_APPROVED,
+}
+
+// priorityFromProto возвращает приоритет модели; UNSPECIFIED — пустой приоритет (NORMAL при создании PR).
+func priorityFromProto(p reviewerpb.Priority) (model.PRPriority, bool) {
+	if p == reviewerpb.Priority_PRIORITY_UNSPECIFIED {
+		return "", true
+	}
+	for priority, value := range priorityToProto {
+		if value == p {
+			return priority, true
+		}
+	}
+	return "", false
+}
+
+// statusFromProto возвращает статус модели; UNSPECIFIED — nil (без фильтра по статусу).
+func statusFromProto(s reviewerpb.PullRequestStatus) (*model.PRStatus, bool) {
+	if s == reviewerpb.PullRequestStatus_PULL_REQUEST_STATUS_UNSPECIFIED {
+		return nil, true
+	}
+	for prStatus, value := range statusToProto {
+		if value == s {
+			return &prStatus, true
+		}
+	}
+	return nil, false
+}
+
+func idStrings(ids []uuid.UUID) []string {
+	values := make([]string, len(ids))
+	for n, id := range ids {
+		values[n] = id.String()
+	}
+	return values
+}
+
+func optionalIDString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	value := id.String()
+	return &value
+}
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// optionalTime возвращает время из необязательного поля запроса.
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func userToProto(u *model.User) *reviewerpb.User {
+	return &reviewerpb.User{
+		UserId:   u.ID.String(),
+		Username: u.Username,
+		TeamId:   u.TeamID.String(),
+		IsActive: u.IsActive,
+		IsSenior: u.IsSenior,
+		Version:  u.Version,
+	}
+}
+
+func usersToProto(users []model.User) []*reviewerpb.User {
+	items := make([]*reviewerpb.User, len(users))
+	for n := range users {
+		items[n] = userToProto(&users[n])
+	}
+	return items
+}
+
+func teamToProto(t *model.Team) *reviewerpb.Team {
+	tiers := make([]*reviewerpb.ReviewerTier, len(t.ReviewerTiers))
+	for n, tier := range t.ReviewerTiers {
+		tiers[n] = &reviewerpb.ReviewerTier{
+			MinLines:        int32(tier.MinLines),
+			HighRisk:        tier.HighRisk,
+			Reviewers:       int32(tier.Reviewers),
+			SeniorReviewers: int32(tier.SeniorReviewers),
+		}
+	}
+	return &reviewerpb.Team{
+		TeamId:                   t.ID.String(),
+		TeamName:                 t.Name,
+		Members:                  usersToProto(t.Members),
+		ShadowingEnabled:         t.ShadowingEnabled,
+		ShadowUserIds:            idStrings(t.ShadowUserIDs),
+		ReviewerTiers:            tiers,
+		ResetApprovalsOnRevision: t.ResetApprovalsOnRevision,
+		Version:                  t.Version,
+	}
+}
+
+func pullRequestToProto(pr *model.PullRequest) *reviewerpb.PullRequest {
+	return &reviewerpb.PullRequest{
+		PullRequestId:     pr.ID.String(),
+		ExternalId:        pr.ExternalID,
+		PullRequestName:   pr.Title,
+		AuthorId:          pr.AuthorID.String(),
+		RepositoryId:      pr.RepositoryID.String(),
+		Reviewers:         idStrings(pr.Reviewers),
+		OptionalReviewers: idStrings(pr.OptionalReviewers),
+		ShadowReviewers:   idStrings(pr.ShadowReviewers),
+		RequiredReviewers: int32(pr.RequiredReviewers),
+		ReviewTeamIds:     idStrings(pr.ReviewTeamIDs),
+		TargetTeamIds:     idStrings(pr.TargetTeamIDs),
+		DependsOn:         idStrings(pr.DependsOn),
+		DiffStats: &reviewerpb.DiffStats{
+			LinesAdded:   int32(pr.DiffStats.LinesAdded),
+			LinesRemoved: int32(pr.DiffStats.LinesRemoved),
+			FilesChanged: int32(pr.DiffStats.FilesChanged),
+			HighRisk:     pr.DiffStats.HighRisk,
+		},
+		Priority:    priorityToProto[pr.Priority],
+		ReviewDueAt: timestamp(pr.ReviewDueAt),
+		Labels:      pr.Labels,
+		Revision:    int32(pr.Revision),
+		ApprovedBy:  idStrings(pr.ApprovedBy),
+		Status:      statusToProto[pr.Status],
+		CreatedAt:   timestamppb.New(pr.CreatedAt),
+		MergedAt:    timestamp(pr.MergedAt),
+		Version:     pr.Version,
+	}
+}
+
+func pullRequestsToProto(prs []model.PullRequest) []*reviewerpb.PullRequest {
+	items := make([]*reviewerpb.PullRequest, len(prs))
+	for n := range prs {
+		items[n] = pullRequestToProto(&prs[n])
+	}
+	return items
+}
+
+func offboardingToProto(report *model.OffboardingReport) *reviewerpb.OffboardingReport {
+	if report == nil {
+		return nil
+	}
+	replacements := make([]*reviewerpb.ReviewerReplacement, len(report.Replacements))
+	for n, r := range report.Replacements {
+		replacements[n] = &reviewerpb.ReviewerReplacement{
+			PullRequestId: r.PRID.String(),
+			OldReviewerId: r.OldReviewerID.String(),
+			NewReviewerId: optionalIDString(r.NewReviewerID),
+			Role:          roleToProto[r.Role],
+		}
+	}
+	return &reviewerpb.OffboardingReport{
+		UserIds:        idStrings(report.UserIDs),
+		Reassigned:     int32(report.Reassigned),
+		ReturnedToPool: int32(report.ReturnedToPool),
+		Removed:        int32(report.Removed),
+		Replacements:   replacements,
+	}
+}
+
+func reviewQueueEntryToProto(e *model.ReviewQueueEntry) *reviewerpb.ReviewQueueEntry {
+	others := make([]*reviewerpb.ReviewerState, len(e.OtherReviewers))
+	for n, r := range e.OtherReviewers {
+		others[n] = &reviewerpb.ReviewerState{
+			UserId:   r.UserID.String(),
+			Username: r.Username,
+			Role:     roleToProto[r.Role],
+			Decision: decisionToProto[r.Decision],
+		}
+	}
+	return &reviewerpb.ReviewQueueEntry{
+		PullRequestId:     e.PRID.String(),
+		PullRequestName:   e.Title,
+		AuthorId:          e.AuthorID.String(),
+		AuthorUsername:    e.AuthorUsername,
+		Role:              roleToProto[e.Role],
+		Priority:          priorityToProto[e.Priority],
+		CreatedAt:         timestamppb.New(e.CreatedAt),
+		ReviewDueAt:       timestamp(e.ReviewDueAt),
+		SlaRemainingHours: e.SLARemainingHours,
+		AuthorBlocked:     e.AuthorBlocked,
+		OtherReviewers:    others,
+		UrgencyScore:      e.Score,
+	}
+}
+
+func revisionToProto(r *model.PRRevision) *reviewerpb.Revision {
+	return &reviewerpb.Revision{
+		RevisionId:        r.ID.String(),
+		PullRequestId:     r.PRID.String(),
+		Number:            int32(r.Number),
+		CommitSha:         r.CommitSHA,
+		ApprovalsReset:    r.ApprovalsReset,
+		RereviewRequested: idStrings(r.RereviewRequested),
+		CreatedAt:         timestamppb.New(r.CreatedAt),
+	}
+}
+
+func eventToProto(e *model.PREvent) *reviewerpb.Event {
+	payload := string(e.Payload)
+	if !json.Valid(e.Payload) {
+		payload = "{}"
+	}
+	return &reviewerpb.Event{
+		EventId:       e.ID.String(),
+		PullRequestId: e.PRID.String(),
+		EventType:     string(e.Type),
+		PayloadJson:   payload,
+		CreatedAt:     timestamppb.New(e.CreatedAt),
+	}
+}
+
+func statisticsToProto(s *model.ReviewStats) *reviewerpb.Statistics {
+	byUser := make([]*reviewerpb.UserAssignmentStats, len(s.AssignmentsByUser))
+	for n, u := range s.AssignmentsByUser {
+		byUser[n] = &reviewerpb.UserAssignmentStats{
+			UserId:              u.UserID,
+			Username:            u.Username,
+			Assignments:         int32(u.Assignments),
+			OptionalAssignments: int32(u.Optional),
+			ShadowAssignments:   int32(u.Shadow),
+			Declines:            int32(u.Declines),
+		}
+	}
+	byPR := make([]*reviewerpb.PullRequestAssignmentStats, len(s.AssignmentsByPR))
+	for n, p := range s.AssignmentsByPR {
+		byPR[n] = &reviewerpb.PullRequestAssignmentStats{
+			PrId:                   p.PRID,
+			PrTitle:                p.PRTitle,
+			ReviewersCount:         int32(p.ReviewersCount),
+			OptionalReviewersCount: int32(p.OptionalCount),
+			Status:                 p.Status,
+		}
+	}
+	bySize := make([]*reviewerpb.SizeMergeStats, len(s.MergeTimeBySize))
+	for n, m := range s.MergeTimeBySize {
+		bySize[n] = &reviewerpb.SizeMergeStats{
+			Size:              m.Size,
+			MergedPrs:         int32(m.MergedPRs),
+			AverageMergeHours: m.AverageMergeHours,
+		}
+	}
+	return &reviewerpb.Statistics{
+		TotalAssignments:      int32(s.TotalAssignments),
+		OptionalAssignments:   int32(s.OptionalAssignments),
+		ShadowAssignments:     int32(s.ShadowAssignments),
+		AssignmentsByUser:     byUser,
+		AssignmentsByPr:       byPR,
+		TotalPrs:              int32(s.TotalPRs),
+		OpenPrs:               int32(s.OpenPRs),
+		MergedPrs:             int32(s.MergedPRs),
+		AverageReviewersPerPr: s.AverageReviewersPerPR,
+		Reassignments:         int32(s.Reassignments),
+		Declines:              int32(s.Declines),
+		MergeTimeBySize:       bySize,
+	}
+}
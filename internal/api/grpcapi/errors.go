@@ -0,0 +1,124 @@
+package grpcapi
+
+import (
+	"avito-assignment/internal/repository"
+	"avito-assignment/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain — домен ошибок в google.rpc.ErrorInfo.
+const errorDomain = "reviewer.avito-assignment"
+
+// codeByKind сопоставляет класс доменной ошибки коду gRPC (аналог statusByKind в handlers).
+var codeByKind = map[service.ErrorKind]codes.Code{
+	service.KindInvalid:            codes.InvalidArgument,
+	service.KindNotFound:           codes.NotFound,
+	service.KindConflict:           codes.FailedPrecondition,
+	service.KindForbidden:          codes.PermissionDenied,
+	service.KindUnprocessable:      codes.InvalidArgument,
+	service.KindPreconditionFailed: codes.Aborted,
+}
+
+// errorInterceptor переводит ошибки сервисного слоя в статусы gRPC, чтобы методы серверов
+// могли возвращать их как есть. Неизвестные ошибки логируются и возвращаются как INTERNAL без подробностей.
+func errorInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err == nil {
+		return resp, nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return nil, err
+	}
+
+	var domainErr *service.Error
+	if errors.As(err, &domainErr) {
+		code, ok := codeByKind[domainErr.Kind]
+		if !ok {
+			code = codes.Internal
+		}
+		return nil, statusError(code, domainErr.Code, domainErr.Message, domainErr.Details)
+	}
+
+	log.Printf("grpc %s: internal error: %v", info.FullMethod, err)
+	return nil, statusError(codes.Internal, "INTERNAL", "internal server error", nil)
+}
+
+// statusError собирает статус gRPC с ErrorInfo: reason — код ошибки REST API,
+// details ошибки передаются в metadata["details"] как JSON.
+func statusError(code codes.Code, reason, message string, details interface{}) error {
+	st := status.New(code, message)
+	info := &errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}
+	if details != nil {
+		if data, err := json.Marshal(details); err == nil {
+			info.Metadata = map[string]string{"details": string(data)}
+		}
+	}
+	withDetails, err := st.WithDetails(info)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// validationError — аналог 400 VALIDATION_ERROR REST API.
+func validationError(message string) error {
+	return statusError(codes.InvalidArgument, "VALIDATION_ERROR", message, nil)
+}
+
+// parseID разбирает обязательный UUID из поля запроса.
+func parseID(field, value string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, validationError(field + " must be a UUID")
+	}
+	return id, nil
+}
+
+// parseOptionalID разбирает необязательный UUID: nil, если поле не задано.
+func parseOptionalID(field string, value *string) (*uuid.UUID, error) {
+	if value == nil {
+		return nil, nil
+	}
+	id, err := parseID(field, *value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// parseIDs разбирает список UUID из поля запроса.
+func parseIDs(field string, values []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(values))
+	for _, value := range values {
+		id, err := parseID(field, value)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// expectedVersion — аналог requireIfMatch: версия обязательна, 0 означает изменение без проверки версии.
+func expectedVersion(version *int64) (int64, error) {
+	if version == nil {
+		return 0, statusError(codes.FailedPrecondition, "PRECONDITION_REQUIRED",
+			"expected_version is required; use 0 to skip the version check", nil)
+	}
+	if *version < 0 {
+		return 0, validationError("expected_version must not be negative")
+	}
+	if *version == 0 {
+		return repository.AnyVersion, nil
+	}
+	return *version, nil
+}
@@ -0,0 +1,77 @@
+package grpcapi
+
+import (
+	"avito-assignment/internal/service"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestErrorInterceptorMapsServiceErrors проверяет, что ошибки сервисов превращаются в статусы gRPC
+// по классу ошибки, а ErrorInfo несет код ошибки REST API и ее подробности.
+func TestErrorInterceptorMapsServiceErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		reason  string
+		message string
+		details string
+	}{
+		{"invalid", service.ErrInvalidPriority, codes.InvalidArgument, "INVALID_PRIORITY", "invalid priority", ""},
+		{"not found", service.ErrPRNotFound, codes.NotFound, "PR_NOT_FOUND", "pull request not found", ""},
+		{"conflict", service.ErrPRMerged, codes.FailedPrecondition, "PR_MERGED", "pull request is already merged", ""},
+		{"forbidden", service.ErrNotEligible, codes.PermissionDenied, "NOT_ELIGIBLE", "user is not eligible to review this PR", ""},
+		{"unprocessable", service.ErrInvalidSnapshot, codes.InvalidArgument, "INVALID_SNAPSHOT",
+			"snapshot is malformed or references missing records", ""},
+		{"precondition failed", service.ErrVersionMismatch, codes.Aborted, "PRECONDITION_FAILED",
+			"resource was modified, re-read it and retry", ""},
+		{"with details", service.ErrSnapshotConflict.WithDetails(map[string]string{"section": "teams", "index": "0"}),
+			codes.FailedPrecondition, "SNAPSHOT_CONFLICT", "snapshot record already exists", `{"index":"0","section":"teams"}`},
+		{"wrapped", fmt.Errorf("claim review: %w", service.ErrReviewerAtCapacity),
+			codes.FailedPrecondition, "AT_CAPACITY", "user has reached the open review limit", ""},
+		{"unknown error", errors.New("connection refused"), codes.Internal, "INTERNAL", "internal server error", ""},
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/reviewer.v1.PullRequestService/GetPullRequest"}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := errorInterceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+				return nil, tc.err
+			})
+			st, ok := status.FromError(err)
+			if !ok {
+				t.Fatalf("expected gRPC status, got %v", err)
+			}
+			if st.Code() != tc.code || st.Message() != tc.message {
+				t.Fatalf("expected %s %q, got %s %q", tc.code, tc.message, st.Code(), st.Message())
+			}
+			errInfo := errorInfo(st)
+			if errInfo == nil {
+				t.Fatal("expected ErrorInfo in status details")
+			}
+			if errInfo.Reason != tc.reason || errInfo.Domain != errorDomain {
+				t.Fatalf("expected reason %s in %s, got %s in %s", tc.reason, errorDomain, errInfo.Reason, errInfo.Domain)
+			}
+			if details := errInfo.Metadata["details"]; details != tc.details {
+				t.Fatalf("expected details %q, got %q", tc.details, details)
+			}
+		})
+	}
+}
+
+// TestErrorInterceptorKeepsStatusErrors проверяет, что готовый статус gRPC возвращается без изменений.
+func TestErrorInterceptorKeepsStatusErrors(t *testing.T) {
+	original := statusError(codes.InvalidArgument, "VALIDATION_ERROR", "user_id must be a UUID", nil)
+	info := &grpc.UnaryServerInfo{FullMethod: "/reviewer.v1.UserService/GetUser"}
+	_, err := errorInterceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, original
+	})
+	if err != original {
+		t.Fatalf("expected the original status error, got %v", err)
+	}
+}
@@ -0,0 +1,336 @@
+package grpcapi
+
+import (
+	"avito-assignment/internal/api/grpcapi/reviewerpb"
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/service"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PullRequestServer реализует reviewer.v1.PullRequestService поверх service.PRService.
+type PullRequestServer struct {
+	reviewerpb.UnimplementedPullRequestServiceServer
+	Service *service.PRService
+}
+
+func (s *PullRequestServer) CreatePullRequest(
+	_ context.Context,
+	req *reviewerpb.CreatePullRequestRequest,
+) (*reviewerpb.CreatePullRequestResponse, error) {
+	authorID, err := parseID("author_id", req.GetAuthorId())
+	if err != nil {
+		return nil, err
+	}
+	repositoryID, err := parseID("repository_id", req.GetRepositoryId())
+	if err != nil {
+		return nil, err
+	}
+	priority, ok := priorityFromProto(req.GetPriority())
+	if !ok {
+		return nil, validationError("unknown priority")
+	}
+	if req.GetReviewSlaHours() < 0 {
+		return nil, validationError("review_sla_hours must not be negative")
+	}
+
+	var dependsOn, targetTeamIDs, requested, excluded []uuid.UUID
+	idLists := []struct {
+		field  string
+		values []string
+		target *[]uuid.UUID
+	}{
+		{"depends_on", req.GetDependsOn(), &dependsOn},
+		{"target_team_ids", req.GetTargetTeamIds(), &targetTeamIDs},
+		{"requested_reviewers", req.GetRequestedReviewers(), &requested},
+		{"excluded_reviewers", req.GetExcludedReviewers(), &excluded},
+	}
+	for _, list := range idLists {
+		if *list.target, err = parseIDs(list.field, list.values); err != nil {
+			return nil, err
+		}
+	}
+
+	externalID := req.ExternalId
+	if externalID != nil && *externalID == "" {
+		externalID = nil
+	}
+	pr := &model.PullRequest{
+		ExternalID:    externalID,
+		Title:         req.GetPullRequestName(),
+		AuthorID:      authorID,
+		RepositoryID:  repositoryID,
+		DependsOn:     dependsOn,
+		TargetTeamIDs: targetTeamIDs,
+		Priority:      priority,
+		Labels:        req.GetLabels(),
+		DiffStats: model.DiffStats{
+			LinesAdded:   int(req.GetDiffStats().GetLinesAdded()),
+			LinesRemoved: int(req.GetDiffStats().GetLinesRemoved()),
+			FilesChanged: int(req.GetDiffStats().GetFilesChanged()),
+			HighRisk:     req.GetDiffStats().GetHighRisk(),
+		},
+	}
+	if req.GetReviewSlaHours() > 0 {
+		due := time.Now().Add(time.Duration(req.GetReviewSlaHours()) * time.Hour)
+		pr.ReviewDueAt = &due
+	}
+
+	result, err := s.Service.CreatePR(pr, model.ReviewerPreferences{
+		Requested: requested,
+		Excluded:  excluded,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &reviewerpb.CreatePullRequestResponse{PullRequest: pullRequestToProto(result.PR), Created: result.Created}
+	for _, rejected := range result.RejectedReviewers {
+		resp.RejectedReviewers = append(resp.RejectedReviewers, &reviewerpb.RejectedReviewer{
+			UserId: rejected.UserID.String(),
+			Reason: string(rejected.Reason),
+		})
+	}
+	if result.Explanation != nil {
+		for _, exclusion := range result.Explanation.ConflictExclusions {
+			resp.ConflictExclusions = append(resp.ConflictExclusions, &reviewerpb.ConflictExclusion{
+				UserId:     exclusion.UserID.String(),
+				Username:   exclusion.Username,
+				ConflictId: exclusion.ConflictRuleID.String(),
+				Reason:     exclusion.Reason,
+			})
+		}
+	}
+	return resp, nil
+}
+
+func (s *PullRequestServer) GetPullRequest(_ context.Context, req *reviewerpb.GetPullRequestRequest) (*reviewerpb.PullRequest, error) {
+	id, err := parseID("pull_request_id", req.GetPullRequestId())
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := s.Service.GetPRByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return pullRequestToProto(pr), nil
+}
+
+func (s *PullRequestServer) ListPullRequests(
+	_ context.Context,
+	req *reviewerpb.ListPullRequestsRequest,
+) (*reviewerpb.ListPullRequestsResponse, error) {
+	prStatus, ok := statusFromProto(req.GetStatus())
+	if !ok {
+		return nil, validationError("unknown status")
+	}
+	filter := model.PRListFilter{
+		Status:      prStatus,
+		Label:       req.GetLabel(),
+		CreatedFrom: optionalTime(req.GetCreatedFrom()),
+		CreatedTo:   optionalTime(req.GetCreatedTo()),
+		MergedFrom:  optionalTime(req.GetMergedFrom()),
+		MergedTo:    optionalTime(req.GetMergedTo()),
+		Sort:        model.PRSortField(req.GetSort()),
+		Descending:  req.GetDescending(),
+		Limit:       int(req.GetLimit()),
+	}
+
+	var err error
+	idFilters := []struct {
+		field  string
+		value  *string
+		target **uuid.UUID
+	}{
+		{"author_id", req.AuthorId, &filter.AuthorID},
+		{"reviewer_id", req.ReviewerId, &filter.ReviewerID},
+		{"team_id", req.TeamId, &filter.TeamID},
+		{"repository_id", req.RepositoryId, &filter.RepositoryID},
+	}
+	for _, f := range idFilters {
+		if *f.target, err = parseOptionalID(f.field, f.value); err != nil {
+			return nil, err
+		}
+	}
+
+	page, err := s.Service.ListPRs(filter, req.GetCursor())
+	if err != nil {
+		return nil, err
+	}
+	return &reviewerpb.ListPullRequestsResponse{Items: pullRequestsToProto(page.Items), NextCursor: page.NextCursor}, nil
+}
+
+func (s *PullRequestServer) MergePullRequest(_ context.Context, req *reviewerpb.MergePullRequestRequest) (*reviewerpb.PullRequest, error) {
+	id, err := parseID("pull_request_id", req.GetPullRequestId())
+	if err != nil {
+		return nil, err
+	}
+	version, err := expectedVersion(req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := s.Service.MergePR(id, version)
+	if err != nil {
+		return nil, err
+	}
+	return pullRequestToProto(merged), nil
+}
+
+func (s *PullRequestServer) ReassignReviewer(
+	_ context.Context,
+	req *reviewerpb.ReassignReviewerRequest,
+) (*reviewerpb.ReassignReviewerResponse, error) {
+	id, err := parseID("pull_request_id", req.GetPullRequestId())
+	if err != nil {
+		return nil, err
+	}
+	oldReviewerID, err := parseID("old_reviewer_id", req.GetOldReviewerId())
+	if err != nil {
+		return nil, err
+	}
+	version, err := expectedVersion(req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, replacedBy, err := s.Service.ReassignReviewer(id, oldReviewerID, version)
+	if err != nil {
+		return nil, err
+	}
+	return &reviewerpb.ReassignReviewerResponse{PullRequest: pullRequestToProto(pr), ReplacedBy: replacedBy.String()}, nil
+}
+
+func (s *PullRequestServer) DeclineReview(_ context.Context, req *reviewerpb.DeclineReviewRequest) (*reviewerpb.DeclineReviewResponse, error) {
+	id, err := parseID("pull_request_id", req.GetPullRequestId())
+	if err != nil {
+		return nil, err
+	}
+	reviewerID, err := parseID("reviewer_id", req.GetReviewerId())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetReason() == "" {
+		return nil, validationError("reason is required")
+	}
+
+	pr, replacedBy, err := s.Service.DeclineReview(id, reviewerID, req.GetReason())
+	if err != nil {
+		return nil, err
+	}
+	resp := &reviewerpb.DeclineReviewResponse{PullRequest: pullRequestToProto(pr)}
+	if replacedBy != uuid.Nil {
+		resp.ReplacedBy = optionalIDString(&replacedBy)
+	}
+	return resp, nil
+}
+
+func (s *PullRequestServer) ClaimReview(_ context.Context, req *reviewerpb.ClaimReviewRequest) (*reviewerpb.PullRequest, error) {
+	id, err := parseID("pull_request_id", req.GetPullRequestId())
+	if err != nil {
+		return nil, err
+	}
+	userID, err := parseID("user_id", req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := s.Service.ClaimReview(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pullRequestToProto(pr), nil
+}
+
+func (s *PullRequestServer) ApproveReview(_ context.Context, req *reviewerpb.ApproveReviewRequest) (*reviewerpb.PullRequest, error) {
+	id, err := parseID("pull_request_id", req.GetPullRequestId())
+	if err != nil {
+		return nil, err
+	}
+	reviewerID, err := parseID("reviewer_id", req.GetReviewerId())
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := s.Service.ApproveReview(id, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	return pullRequestToProto(pr), nil
+}
+
+func (s *PullRequestServer) PushRevision(_ context.Context, req *reviewerpb.PushRevisionRequest) (*reviewerpb.PushRevisionResponse, error) {
+	id, err := parseID("pull_request_id", req.GetPullRequestId())
+	if err != nil {
+		return nil, err
+	}
+
+	pr, revision, err := s.Service.PushRevision(id, req.CommitSha)
+	if err != nil {
+		return nil, err
+	}
+	return &reviewerpb.PushRevisionResponse{PullRequest: pullRequestToProto(pr), Revision: revisionToProto(revision)}, nil
+}
+
+func (s *PullRequestServer) AddDependencies(_ context.Context, req *reviewerpb.AddDependenciesRequest) (*reviewerpb.PullRequest, error) {
+	id, err := parseID("pull_request_id", req.GetPullRequestId())
+	if err != nil {
+		return nil, err
+	}
+	if len(req.GetDependsOn()) == 0 {
+		return nil, validationError("depends_on is required")
+	}
+	dependsOn, err := parseIDs("depends_on", req.GetDependsOn())
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := s.Service.AddDependencies(id, dependsOn)
+	if err != nil {
+		return nil, err
+	}
+	return pullRequestToProto(pr), nil
+}
+
+func (s *PullRequestServer) AddOptionalReviewers(
+	_ context.Context,
+	req *reviewerpb.AddOptionalReviewersRequest,
+) (*reviewerpb.PullRequest, error) {
+	id, err := parseID("pull_request_id", req.GetPullRequestId())
+	if err != nil {
+		return nil, err
+	}
+	if len(req.GetUserIds()) == 0 {
+		return nil, validationError("user_ids is required")
+	}
+	userIDs, err := parseIDs("user_ids", req.GetUserIds())
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := s.Service.AddOptionalReviewers(id, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	return pullRequestToProto(pr), nil
+}
+
+func (s *PullRequestServer) ListEvents(_ context.Context, req *reviewerpb.ListEventsRequest) (*reviewerpb.ListEventsResponse, error) {
+	id, err := parseID("pull_request_id", req.GetPullRequestId())
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.Service.GetPREvents(id)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*reviewerpb.Event, len(events))
+	for n := range events {
+		items[n] = eventToProto(&events[n])
+	}
+	return &reviewerpb.ListEventsResponse{Events: items}, nil
+}
@@ -0,0 +1,31 @@
+// Package grpcapi реализует gRPC API (api/proto/reviewer/v1/reviewer.proto) поверх тех же
+// сервисов, что и REST API, поэтому правила назначения, проверки и ошибки у двух API общие.
+package grpcapi
+
+import (
+	"avito-assignment/internal/api/grpcapi/reviewerpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Servers объединяет реализации gRPC сервисов, из которых собирается сервер.
+type Servers struct {
+	User       *UserServer
+	Team       *TeamServer
+	PR         *PullRequestServer
+	Statistics *StatisticsServer
+}
+
+// NewServer регистрирует все gRPC сервисы. Ошибки сервисного слоя переводятся
+// в статусы gRPC перехватчиком (см. errorInterceptor). Reflection включен,
+// чтобы grpcurl и похожие инструменты работали без .proto файла.
+func NewServer(s Servers) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(errorInterceptor))
+	reviewerpb.RegisterUserServiceServer(server, s.User)
+	reviewerpb.RegisterTeamServiceServer(server, s.Team)
+	reviewerpb.RegisterPullRequestServiceServer(server, s.PR)
+	reviewerpb.RegisterStatisticsServiceServer(server, s.Statistics)
+	reflection.Register(server)
+	return server
+}
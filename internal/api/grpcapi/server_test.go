@@ -0,0 +1,95 @@
+package grpcapi
+
+import (
+	"avito-assignment/internal/api/grpcapi/reviewerpb"
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestGRPCRejectsInvalidRequests проверяет, что gRPC API отклоняет некорректные запросы
+// и изменения без expected_version до обращения к сервисам, с кодом ошибки REST API в ErrorInfo.
+func TestGRPCRejectsInvalidRequests(t *testing.T) {
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(Servers{
+		User:       &UserServer{},
+		Team:       &TeamServer{},
+		PR:         &PullRequestServer{},
+		Statistics: &StatisticsServer{},
+	})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	users := reviewerpb.NewUserServiceClient(conn)
+	teams := reviewerpb.NewTeamServiceClient(conn)
+	prs := reviewerpb.NewPullRequestServiceClient(conn)
+	const id = "6f1c1a52-0d7b-4c59-9f0a-3c0f8f0f6b11"
+	cases := []struct {
+		name   string
+		call   func(ctx context.Context) error
+		code   codes.Code
+		reason string
+	}{
+		{"invalid UUID", func(ctx context.Context) error {
+			_, err := users.GetUser(ctx, &reviewerpb.GetUserRequest{UserId: "not-a-uuid"})
+			return err
+		}, codes.InvalidArgument, "VALIDATION_ERROR"},
+		{"update without expected_version", func(ctx context.Context) error {
+			_, err := users.UpdateUser(ctx, &reviewerpb.UpdateUserRequest{UserId: id, Username: "alice", TeamId: id})
+			return err
+		}, codes.FailedPrecondition, "PRECONDITION_REQUIRED"},
+		{"upsert without expected_version", func(ctx context.Context) error {
+			_, err := teams.UpsertTeam(ctx, &reviewerpb.UpsertTeamRequest{TeamName: "backend"})
+			return err
+		}, codes.FailedPrecondition, "PRECONDITION_REQUIRED"},
+		{"merge without expected_version", func(ctx context.Context) error {
+			_, err := prs.MergePullRequest(ctx, &reviewerpb.MergePullRequestRequest{PullRequestId: id})
+			return err
+		}, codes.FailedPrecondition, "PRECONDITION_REQUIRED"},
+		{"unknown priority", func(ctx context.Context) error {
+			_, err := prs.CreatePullRequest(ctx, &reviewerpb.CreatePullRequestRequest{
+				PullRequestName: "x", AuthorId: id, RepositoryId: id, Priority: reviewerpb.Priority(42)})
+			return err
+		}, codes.InvalidArgument, "VALIDATION_ERROR"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := status.Convert(tc.call(context.Background()))
+			if st.Code() != tc.code {
+				t.Fatalf("expected %s, got %s: %s", tc.code, st.Code(), st.Message())
+			}
+			if reason := errorInfo(st).GetReason(); reason != tc.reason {
+				t.Fatalf("expected reason %s, got %q", tc.reason, reason)
+			}
+		})
+	}
+}
+
+// errorInfo возвращает ErrorInfo из подробностей статуса или nil, если его нет.
+func errorInfo(st *status.Status) *errdetails.ErrorInfo {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package grpcapi
+
+import (
+	"avito-assignment/internal/api/grpcapi/reviewerpb"
+	"avito-assignment/internal/service"
+	"context"
+)
+
+// StatisticsServer реализует reviewer.v1.StatisticsService поверх service.StatisticsService.
+type StatisticsServer struct {
+	reviewerpb.UnimplementedStatisticsServiceServer
+	Service *service.StatisticsService
+}
+
+func (s *StatisticsServer) GetStatistics(_ context.Context, _ *reviewerpb.GetStatisticsRequest) (*reviewerpb.Statistics, error) {
+	stats, err := s.Service.GetReviewStats()
+	if err != nil {
+		return nil, err
+	}
+	return statisticsToProto(stats), nil
+}
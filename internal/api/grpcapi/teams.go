@@ -0,0 +1,176 @@
+package grpcapi
+
+import (
+	"avito-assignment/internal/api/grpcapi/reviewerpb"
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/service"
+	"context"
+)
+
+// TeamServer реализует reviewer.v1.TeamService поверх service.TeamService.
+// PRService нужен для переназначения ревью ушедших участников и очереди ревью команды.
+type TeamServer struct {
+	reviewerpb.UnimplementedTeamServiceServer
+	Service   *service.TeamService
+	PRService *service.PRService
+}
+
+func (s *TeamServer) CreateTeam(_ context.Context, req *reviewerpb.CreateTeamRequest) (*reviewerpb.Team, error) {
+	team := &model.Team{Name: req.GetTeamName()}
+	for _, member := range req.GetMembers() {
+		team.Members = append(team.Members, model.User{
+			Username: member.GetUsername(),
+			IsActive: member.GetIsActive(),
+			IsSenior: member.GetIsSenior(),
+		})
+	}
+
+	created, err := s.Service.CreateTeam(team)
+	if err != nil {
+		return nil, err
+	}
+	return teamToProto(created), nil
+}
+
+func (s *TeamServer) GetTeam(_ context.Context, req *reviewerpb.GetTeamRequest) (*reviewerpb.Team, error) {
+	id, err := parseID("team_id", req.GetTeamId())
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := s.Service.GetTeamByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return teamToProto(team), nil
+}
+
+func (s *TeamServer) UpdateTeam(_ context.Context, req *reviewerpb.UpdateTeamRequest) (*reviewerpb.Team, error) {
+	id, err := parseID("team_id", req.GetTeamId())
+	if err != nil {
+		return nil, err
+	}
+	version, err := expectedVersion(req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.Service.UpdateTeam(&model.Team{ID: id, Name: req.GetTeamName()}, version)
+	if err != nil {
+		return nil, err
+	}
+	return teamToProto(updated), nil
+}
+
+func (s *TeamServer) DeleteTeam(_ context.Context, req *reviewerpb.DeleteTeamRequest) (*reviewerpb.DeleteTeamResponse, error) {
+	id, err := parseID("team_id", req.GetTeamId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.Service.DeleteTeam(id); err != nil {
+		return nil, err
+	}
+	return &reviewerpb.DeleteTeamResponse{}, nil
+}
+
+func (s *TeamServer) ListTeams(_ context.Context, req *reviewerpb.ListTeamsRequest) (*reviewerpb.ListTeamsResponse, error) {
+	page, err := s.Service.ListTeams(model.TeamListFilter{
+		Search: req.GetSearch(),
+		Limit:  int(req.GetLimit()),
+	}, req.GetCursor())
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*reviewerpb.TeamSummary, len(page.Items))
+	for n, t := range page.Items {
+		items[n] = &reviewerpb.TeamSummary{
+			TeamId:            t.ID.String(),
+			TeamName:          t.Name,
+			MemberCount:       int32(t.MemberCount),
+			ActiveMemberCount: int32(t.ActiveMemberCount),
+		}
+	}
+	return &reviewerpb.ListTeamsResponse{Items: items, NextCursor: page.NextCursor}, nil
+}
+
+func (s *TeamServer) UpsertTeam(_ context.Context, req *reviewerpb.UpsertTeamRequest) (*reviewerpb.UpsertTeamResponse, error) {
+	version, err := expectedVersion(req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	upsert := model.TeamUpsertRequest{Name: req.GetTeamName(), Members: []model.TeamUpsertMember{}}
+	for _, member := range req.GetMembers() {
+		userID, err := parseOptionalID("members.user_id", member.UserId)
+		if err != nil {
+			return nil, err
+		}
+		upsert.Members = append(upsert.Members, model.TeamUpsertMember{
+			UserID:   userID,
+			Username: member.GetUsername(),
+			IsActive: member.IsActive,
+			IsSenior: member.IsSenior,
+		})
+	}
+
+	result, err := s.Service.UpsertTeam(upsert, version, s.PRService)
+	if err != nil {
+		return nil, err
+	}
+
+	moves := make([]*reviewerpb.MemberMove, len(result.Diff.AddedMembers))
+	for n, move := range result.Diff.AddedMembers {
+		moves[n] = &reviewerpb.MemberMove{UserId: move.UserID.String(), FromTeamId: optionalIDString(move.FromTeamID)}
+	}
+	return &reviewerpb.UpsertTeamResponse{
+		Team: teamToProto(result.Team),
+		Diff: &reviewerpb.TeamUpsertDiff{
+			TeamCreated:    result.Diff.TeamCreated,
+			CreatedUsers:   idStrings(result.Diff.CreatedUsers),
+			AddedMembers:   moves,
+			RemovedMembers: idStrings(result.Diff.RemovedMembers),
+			UpdatedUsers:   idStrings(result.Diff.UpdatedUsers),
+		},
+		Offboarding: offboardingToProto(result.Offboarding),
+	}, nil
+}
+
+func (s *TeamServer) DeactivateTeamMembers(
+	_ context.Context,
+	req *reviewerpb.DeactivateTeamMembersRequest,
+) (*reviewerpb.DeactivateTeamMembersResponse, error) {
+	id, err := parseID("team_id", req.GetTeamId())
+	if err != nil {
+		return nil, err
+	}
+
+	count, report, err := s.Service.DeactivateTeamMembers(id, s.PRService)
+	if err != nil {
+		return nil, err
+	}
+	return &reviewerpb.DeactivateTeamMembersResponse{
+		DeactivatedCount: int32(count),
+		Offboarding:      offboardingToProto(report),
+	}, nil
+}
+
+func (s *TeamServer) GetReviewPool(_ context.Context, req *reviewerpb.GetReviewPoolRequest) (*reviewerpb.GetReviewPoolResponse, error) {
+	id, err := parseID("team_id", req.GetTeamId())
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := s.PRService.GetReviewPool(id)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*reviewerpb.ReviewPoolEntry, len(pool))
+	for n := range pool {
+		entries[n] = &reviewerpb.ReviewPoolEntry{
+			PullRequest: pullRequestToProto(&pool[n].PullRequest),
+			OpenSlots:   int32(pool[n].OpenSlots),
+		}
+	}
+	return &reviewerpb.GetReviewPoolResponse{Entries: entries}, nil
+}
@@ -0,0 +1,135 @@
+package grpcapi
+
+import (
+	"avito-assignment/internal/api/grpcapi/reviewerpb"
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/service"
+	"context"
+)
+
+// UserServer реализует reviewer.v1.UserService поверх service.UserService.
+type UserServer struct {
+	reviewerpb.UnimplementedUserServiceServer
+	Service *service.UserService
+}
+
+func (s *UserServer) CreateUser(_ context.Context, req *reviewerpb.CreateUserRequest) (*reviewerpb.User, error) {
+	teamID, err := parseID("team_id", req.GetTeamId())
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.Service.CreateUser(&model.User{
+		Username: req.GetUsername(),
+		TeamID:   teamID,
+		IsActive: req.GetIsActive(),
+		IsSenior: req.GetIsSenior(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return userToProto(created), nil
+}
+
+func (s *UserServer) GetUser(_ context.Context, req *reviewerpb.GetUserRequest) (*reviewerpb.User, error) {
+	id, err := parseID("user_id", req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.Service.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return userToProto(user), nil
+}
+
+func (s *UserServer) UpdateUser(_ context.Context, req *reviewerpb.UpdateUserRequest) (*reviewerpb.UpdateUserResponse, error) {
+	id, err := parseID("user_id", req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	teamID, err := parseID("team_id", req.GetTeamId())
+	if err != nil {
+		return nil, err
+	}
+	version, err := expectedVersion(req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, report, err := s.Service.UpdateUser(&model.User{
+		ID:       id,
+		Username: req.GetUsername(),
+		TeamID:   teamID,
+		IsActive: req.GetIsActive(),
+		IsSenior: req.GetIsSenior(),
+	}, version)
+	if err != nil {
+		return nil, err
+	}
+	return &reviewerpb.UpdateUserResponse{User: userToProto(updated), Offboarding: offboardingToProto(report)}, nil
+}
+
+func (s *UserServer) DeleteUser(_ context.Context, req *reviewerpb.DeleteUserRequest) (*reviewerpb.DeleteUserResponse, error) {
+	id, err := parseID("user_id", req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.Service.DeleteUser(id); err != nil {
+		return nil, err
+	}
+	return &reviewerpb.DeleteUserResponse{}, nil
+}
+
+func (s *UserServer) ListUsers(_ context.Context, req *reviewerpb.ListUsersRequest) (*reviewerpb.ListUsersResponse, error) {
+	teamID, err := parseOptionalID("team_id", req.TeamId)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.Service.ListUsers(model.UserListFilter{
+		Search:   req.GetSearch(),
+		TeamID:   teamID,
+		IsActive: req.IsActive,
+		Limit:    int(req.GetLimit()),
+	}, req.GetCursor())
+	if err != nil {
+		return nil, err
+	}
+	return &reviewerpb.ListUsersResponse{Items: usersToProto(page.Items), NextCursor: page.NextCursor}, nil
+}
+
+func (s *UserServer) ListAssignedPullRequests(
+	_ context.Context,
+	req *reviewerpb.ListAssignedPullRequestsRequest,
+) (*reviewerpb.ListAssignedPullRequestsResponse, error) {
+	id, err := parseID("user_id", req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	prs, err := s.Service.GetAssignedPRs(id)
+	if err != nil {
+		return nil, err
+	}
+	return &reviewerpb.ListAssignedPullRequestsResponse{PullRequests: pullRequestsToProto(prs)}, nil
+}
+
+func (s *UserServer) GetReviewQueue(_ context.Context, req *reviewerpb.GetReviewQueueRequest) (*reviewerpb.GetReviewQueueResponse, error) {
+	id, err := parseID("user_id", req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	queue, err := s.Service.GetReviewQueue(id)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*reviewerpb.ReviewQueueEntry, len(queue))
+	for n := range queue {
+		entries[n] = reviewQueueEntryToProto(&queue[n])
+	}
+	return &reviewerpb.GetReviewQueueResponse{Entries: entries}, nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/service"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// ConflictHandler обрабатывает HTTP запросы управления правилами конфликта интересов.
+type ConflictHandler struct {
+	Service *service.ConflictService
+}
+
+// CreateConflictRequest представляет запрос на добавление правила конфликта интересов.
+// Mutual по умолчанию true: пара пользователей не ревьюит друг друга.
+type CreateConflictRequest struct {
+	ReviewerID uuid.UUID `json:"reviewer_id"`
+	AuthorID   uuid.UUID `json:"author_id"`
+	Mutual     *bool     `json:"mutual,omitempty"`
+	Reason     string    `json:"reason"`
+}
+
+func (h *ConflictHandler) CreateConflict(w http.ResponseWriter, r *http.Request) {
+	var req CreateConflictRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+
+	rule := &model.ConflictRule{
+		ReviewerID: req.ReviewerID,
+		AuthorID:   req.AuthorID,
+		Mutual:     req.Mutual == nil || *req.Mutual,
+		Reason:     req.Reason,
+	}
+
+	created, err := h.Service.CreateConflict(rule)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(created)
+	if err != nil {
+		return
+	}
+}
+
+func (h *ConflictHandler) GetConflicts(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.Service.GetAllConflicts()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(rules)
+	if err != nil {
+		return
+	}
+}
+
+func (h *ConflictHandler) DeleteConflict(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["conflict_id"])
+	if err != nil {
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+
+	err = h.Service.DeleteConflict(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
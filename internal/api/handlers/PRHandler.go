@@ -5,6 +5,7 @@ import (
 	"avito-assignment/internal/service"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -17,8 +18,63 @@ type PRHandler struct {
 
 // CreatePRRequest представляет запрос на создание Pull Request.
 type CreatePRRequest struct {
-	Title    string    `json:"pull_request_name"`
-	AuthorID uuid.UUID `json:"author_id"`
+	ExternalID   *string     `json:"external_id,omitempty"`
+	Title        string      `json:"pull_request_name"`
+	AuthorID     uuid.UUID   `json:"author_id"`
+	RepositoryID uuid.UUID   `json:"repository_id"`
+	DependsOn    []uuid.UUID `json:"depends_on,omitempty"`
+	// RequestedReviewers назначаются всегда, если подходят; ExcludedReviewers не назначаются никогда
+	RequestedReviewers []uuid.UUID `json:"requested_reviewers,omitempty"`
+	ExcludedReviewers  []uuid.UUID `json:"excluded_reviewers,omitempty"`
+	// Размер и риск изменений определяют число ревьюверов по уровням команды
+	LinesAdded   int  `json:"lines_added"`
+	LinesRemoved int  `json:"lines_removed"`
+	FilesChanged int  `json:"files_changed"`
+	HighRisk     bool `json:"high_risk"`
+	// TargetTeamIDs делает PR кросс-командным: ревьюверы и одобрения нужны от каждой команды
+	TargetTeamIDs []uuid.UUID `json:"target_team_ids,omitempty"`
+	// Priority — LOW, NORMAL (по умолчанию), HIGH или CRITICAL; ReviewSLAHours задает срок ревью
+	Priority       model.PRPriority `json:"priority,omitempty"`
+	ReviewSLAHours int              `json:"review_sla_hours,omitempty"`
+	Labels         []string         `json:"labels,omitempty"`
+}
+
+// CreatePRResponse представляет ответ на создание PR.
+type CreatePRResponse struct {
+	*model.PullRequest
+	RejectedReviewers []model.RejectedReviewer     `json:"rejected_reviewers,omitempty"`
+	Explanation       *model.AssignmentExplanation `json:"assignment_explanation,omitempty"`
+}
+
+// DeclineReviewRequest представляет запрос ревьювера на самоотвод.
+type DeclineReviewRequest struct {
+	ReviewerID uuid.UUID `json:"reviewer_id"`
+	Reason     string    `json:"reason"`
+}
+
+// ClaimReviewRequest представляет запрос пользователя взять PR на ревью из очереди.
+type ClaimReviewRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// AddOptionalReviewersRequest представляет запрос на добавление опциональных ревьюверов.
+type AddOptionalReviewersRequest struct {
+	UserIDs []uuid.UUID `json:"user_ids"`
+}
+
+// AddDependenciesRequest представляет запрос на добавление зависимостей PR.
+type AddDependenciesRequest struct {
+	DependsOn []uuid.UUID `json:"depends_on"`
+}
+
+// ApproveReviewRequest представляет одобрение текущей ревизии PR ревьювером.
+type ApproveReviewRequest struct {
+	ReviewerID uuid.UUID `json:"reviewer_id"`
+}
+
+// PushRevisionRequest представляет новую ревизию (пуш) PR.
+type PushRevisionRequest struct {
+	CommitSHA *string `json:"commit_sha,omitempty"`
 }
 
 // ReassignReviewerRequest представляет запрос на переназначение ревьювера.
@@ -30,38 +86,61 @@ type ReassignReviewerRequest struct {
 func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	var req CreatePRRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeValidationError(w, "invalid request body")
+		return
+	}
+	if req.ExternalID != nil && *req.ExternalID == "" {
+		req.ExternalID = nil
+	}
+	if req.ReviewSLAHours < 0 {
+		writeValidationError(w, "review_sla_hours must not be negative")
 		return
 	}
 
 	pr := &model.PullRequest{
-		Title:    req.Title,
-		AuthorID: req.AuthorID,
+		ExternalID:    req.ExternalID,
+		Title:         req.Title,
+		AuthorID:      req.AuthorID,
+		RepositoryID:  req.RepositoryID,
+		DependsOn:     req.DependsOn,
+		TargetTeamIDs: req.TargetTeamIDs,
+		Priority:      req.Priority,
+		Labels:        req.Labels,
+		DiffStats: model.DiffStats{
+			LinesAdded:   req.LinesAdded,
+			LinesRemoved: req.LinesRemoved,
+			FilesChanged: req.FilesChanged,
+			HighRisk:     req.HighRisk,
+		},
 	}
 
-	createdPR, err := h.Service.CreatePR(pr)
-	if err != nil {
-		if err.Error() == "author not found" {
-			http.Error(w, "Автор/команда не найдены", http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if req.ReviewSLAHours > 0 {
+		due := time.Now().Add(time.Duration(req.ReviewSLAHours) * time.Hour)
+		pr.ReviewDueAt = &due
 	}
 
-	pullReq, errGetting := h.Service.GetPRByID(createdPR.ID)
-	if errGetting != nil {
-		http.Error(w, errGetting.Error(), http.StatusInternalServerError)
+	result, err := h.Service.CreatePR(pr, model.ReviewerPreferences{
+		Requested: req.RequestedReviewers,
+		Excluded:  req.ExcludedReviewers,
+	})
+	if err != nil {
+		writeError(w, err)
 		return
 	}
-	if pullReq != nil {
-		http.Error(w, "PR уже существует", http.StatusConflict)
-		return
+
+	// Повторный запрос с тем же external_id возвращает уже созданный PR
+	status := http.StatusOK
+	if result.Created {
+		status = http.StatusCreated
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	err = json.NewEncoder(w).Encode("PR создан")
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(CreatePRResponse{
+		PullRequest:       result.PR,
+		RejectedReviewers: result.RejectedReviewers,
+		Explanation:       result.Explanation,
+	})
 	if err != nil {
 		return
 	}
@@ -72,16 +151,17 @@ func (h *PRHandler) GetPR(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["pull_request_id"]
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, "invalid UUID", http.StatusBadRequest)
+		writeValidationError(w, "invalid UUID")
 		return
 	}
 
 	pr, err := h.Service.GetPRByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, err)
 		return
 	}
 
+	setETag(w, pr.Version)
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(pr)
 	if err != nil {
@@ -92,93 +172,44 @@ func (h *PRHandler) GetPR(w http.ResponseWriter, r *http.Request) {
 func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	expectedVersion, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		PullRequestID string `json:"pull_request_id"`
 		OldUserID     string `json:"old_user_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		writeValidationError(w, "invalid request body")
 		return
 	}
 
 	if req.PullRequestID == "" || req.OldUserID == "" {
-		http.Error(w, `{"error":"pull_request_id and old_user_id are required"}`, http.StatusBadRequest)
+		writeValidationError(w, "pull_request_id and old_user_id are required")
 		return
 	}
 
 	prID, err := uuid.Parse(req.PullRequestID)
 	if err != nil {
-		http.Error(w, `{"error":"invalid pull_request_id (must be UUID)"}`, http.StatusBadRequest)
+		writeValidationError(w, "invalid pull_request_id (must be UUID)")
 		return
 	}
 
 	oldUserID, err := uuid.Parse(req.OldUserID)
 	if err != nil {
-		http.Error(w, `{"error":"invalid old_user_id (must be UUID)"}`, http.StatusBadRequest)
+		writeValidationError(w, "invalid old_user_id (must be UUID)")
 		return
 	}
 
-	updatedPR, replacedBy, err := h.Service.ReassignReviewer(prID, oldUserID)
+	updatedPR, replacedBy, err := h.Service.ReassignReviewer(prID, oldUserID, expectedVersion)
 	if err != nil {
-		switch err.Error() {
-		case "pull request not found", "user not found":
-			w.WriteHeader(http.StatusNotFound)
-			err = json.NewEncoder(w).Encode(map[string]interface{}{
-				"error": map[string]string{
-					"code":    "NOT_FOUND",
-					"message": "PR или пользователь не найден\n",
-				},
-			})
-			if err != nil {
-				return
-			}
-			return
-
-		case "cannot reassign reviewers for merged PR":
-			w.WriteHeader(http.StatusConflict)
-			err = json.NewEncoder(w).Encode(map[string]interface{}{
-				"error": map[string]string{
-					"code":    "PR_MERGED",
-					"message": "Нельзя менять после MERGED",
-				},
-			})
-			if err != nil {
-				return
-			}
-			return
-
-		case "reviewer not assigned to this PR":
-			w.WriteHeader(http.StatusConflict)
-			err = json.NewEncoder(w).Encode(map[string]interface{}{
-				"error": map[string]string{
-					"code":    "NOT_ASSIGNED",
-					"message": "Пользователь не был назначен ревьювером",
-				},
-			})
-			if err != nil {
-				return
-			}
-			return
-
-		case "no available reviewers in the team":
-			w.WriteHeader(http.StatusConflict)
-			err = json.NewEncoder(w).Encode(map[string]interface{}{
-				"error": map[string]string{
-					"code":    "NO_CANDIDATE",
-					"message": "Нет доступных кандидатов",
-				},
-			})
-			if err != nil {
-				return
-			}
-			return
-		}
-
-		// fallback
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
+	setETag(w, updatedPR.Version)
 
 	// 5. Возвращаем успешный ответ
 	err = json.NewEncoder(w).Encode(map[string]interface{}{
@@ -194,66 +225,340 @@ func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	expectedVersion, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		PullRequestID string `json:"pull_request_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "invalid request body"}`, http.StatusBadRequest)
+		writeValidationError(w, "invalid request body")
 		return
 	}
 
 	if req.PullRequestID == "" {
-		http.Error(w, `{"error": "pull_request_id is required"}`, http.StatusBadRequest)
+		writeValidationError(w, "pull_request_id is required")
 		return
 	}
 
 	prID, err := uuid.Parse(req.PullRequestID)
 	if err != nil {
-		http.Error(w, `{"error": "invalid pull_request_id (must be UUID)"}`, http.StatusBadRequest)
+		writeValidationError(w, "invalid pull_request_id (must be UUID)")
 		return
 	}
 
-	_, errMerged := h.Service.MergePR(prID)
+	mergedPR, errMerged := h.Service.MergePR(prID, expectedVersion)
 	if errMerged != nil {
-		if errMerged.Error() == "pull request not found" {
-			w.WriteHeader(http.StatusNotFound)
-			err = json.NewEncoder(w).Encode(map[string]string{
-				"error": "PR не найден",
-			})
-			if err != nil {
-				return
-			}
+		writeError(w, errMerged)
+		return
+	}
+	setETag(w, mergedPR.Version)
+
+	err = json.NewEncoder(w).Encode(map[string]interface{}{
+		"pr": "PR в состоянии MERGED",
+	})
+	if err != nil {
+		return
+	}
+}
+
+// ListPRs возвращает страницу PR с фильтрами, сортировкой и курсорной пагинацией.
+func (h *PRHandler) ListPRs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := model.PRListFilter{
+		Label: query.Get("label"),
+		Sort:  model.PRSortField(query.Get("sort")),
+	}
+	if status := query.Get("status"); status != "" {
+		prStatus := model.PRStatus(status)
+		filter.Status = &prStatus
+	}
+
+	var err error
+	uuidParams := []struct {
+		name   string
+		target **uuid.UUID
+	}{
+		{"author_id", &filter.AuthorID},
+		{"reviewer_id", &filter.ReviewerID},
+		{"team_id", &filter.TeamID},
+		{"repository_id", &filter.RepositoryID},
+	}
+	for _, p := range uuidParams {
+		if *p.target, err = queryUUID(query, p.name); err != nil {
+			writeValidationError(w, err.Error())
 			return
 		}
-
-		w.WriteHeader(http.StatusInternalServerError)
-		err = json.NewEncoder(w).Encode(map[string]string{
-			"error": errMerged.Error(),
-		})
-		if err != nil {
+	}
+	timeParams := []struct {
+		name   string
+		target **time.Time
+	}{
+		{"created_from", &filter.CreatedFrom},
+		{"created_to", &filter.CreatedTo},
+		{"merged_from", &filter.MergedFrom},
+		{"merged_to", &filter.MergedTo},
+	}
+	for _, p := range timeParams {
+		if *p.target, err = queryTime(query, p.name); err != nil {
+			writeValidationError(w, err.Error())
 			return
 		}
+	}
+	if filter.Descending, err = queryDescending(query); err != nil {
+		writeValidationError(w, err.Error())
+		return
+	}
+	if filter.Limit, err = queryInt(query, "limit"); err != nil {
+		writeValidationError(w, err.Error())
+		return
+	}
+
+	page, err := h.Service.ListPRs(filter, query.Get("cursor"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(page)
+	if err != nil {
+		return
+	}
+}
+
+// AddDependencies добавляет PR зависимости от других PR (стек PR).
+func (h *PRHandler) AddDependencies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	prID, err := uuid.Parse(vars["pull_request_id"])
+	if err != nil {
+		writeValidationError(w, "invalid pull_request_id (must be UUID)")
+		return
+	}
+
+	var req AddDependenciesRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+	if len(req.DependsOn) == 0 {
+		writeValidationError(w, "depends_on is required")
+		return
+	}
+
+	pr, err := h.Service.AddDependencies(prID, req.DependsOn)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(pr)
+	if err != nil {
+		return
+	}
+}
+
+// GetPREvents возвращает события (уведомления) по PR.
+func (h *PRHandler) GetPREvents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	prID, err := uuid.Parse(vars["pull_request_id"])
+	if err != nil {
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+
+	events, err := h.Service.GetPREvents(prID)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(events)
+	if err != nil {
+		return
+	}
+}
+
+// DeclineReview снимает ревьювера с PR по его просьбе и назначает замену.
+func (h *PRHandler) DeclineReview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	prID, err := uuid.Parse(vars["pull_request_id"])
+	if err != nil {
+		writeValidationError(w, "invalid pull_request_id (must be UUID)")
+		return
+	}
+
+	var req DeclineReviewRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+	if req.ReviewerID == uuid.Nil || req.Reason == "" {
+		writeValidationError(w, "reviewer_id and reason are required")
+		return
+	}
+
+	updatedPR, replacedBy, err := h.Service.DeclineReview(prID, req.ReviewerID, req.Reason)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var replacement *uuid.UUID
+	if replacedBy != uuid.Nil {
+		replacement = &replacedBy
+	}
+
 	err = json.NewEncoder(w).Encode(map[string]interface{}{
-		"pr": "PR в состоянии MERGED",
+		"message":     "Ревьювер отказался от ревью",
+		"pr":          updatedPR,
+		"replaced_by": replacement,
 	})
 	if err != nil {
 		return
 	}
 }
 
-func (h *PRHandler) GetAllPRs(w http.ResponseWriter, r *http.Request) {
-	prs, err := h.Service.GetAllPRs()
+// ClaimReview назначает пользователя ревьювером PR из очереди неназначенных ревью.
+func (h *PRHandler) ClaimReview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	prID, err := uuid.Parse(vars["pull_request_id"])
+	if err != nil {
+		writeValidationError(w, "invalid pull_request_id (must be UUID)")
+		return
+	}
+
+	var req ClaimReviewRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+	if req.UserID == uuid.Nil {
+		writeValidationError(w, "user_id is required")
+		return
+	}
+
+	pr, err := h.Service.ClaimReview(prID, req.UserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(pr)
+	if err != nil {
+		return
+	}
+}
+
+// AddOptionalReviewers добавляет к PR опциональных ревьюверов (наблюдателей).
+func (h *PRHandler) AddOptionalReviewers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	prID, err := uuid.Parse(vars["pull_request_id"])
+	if err != nil {
+		writeValidationError(w, "invalid pull_request_id (must be UUID)")
+		return
+	}
+
+	var req AddOptionalReviewersRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+	if len(req.UserIDs) == 0 {
+		writeValidationError(w, "user_ids is required")
+		return
+	}
+
+	pr, err := h.Service.AddOptionalReviewers(prID, req.UserIDs)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(pr)
+	if err != nil {
+		return
+	}
+}
+
+// ApproveReview одобряет текущую ревизию PR от имени ревьювера.
+func (h *PRHandler) ApproveReview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	prID, err := uuid.Parse(vars["pull_request_id"])
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeValidationError(w, "invalid pull_request_id (must be UUID)")
 		return
 	}
 
+	var req ApproveReviewRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+	if req.ReviewerID == uuid.Nil {
+		writeValidationError(w, "reviewer_id is required")
+		return
+	}
+
+	pr, err := h.Service.ApproveReview(prID, req.ReviewerID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(pr)
+	if err != nil {
+		return
+	}
+}
+
+// PushRevision записывает новую ревизию PR и запрашивает повторное ревью по политике команды.
+func (h *PRHandler) PushRevision(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(prs)
+
+	vars := mux.Vars(r)
+	prID, err := uuid.Parse(vars["pull_request_id"])
+	if err != nil {
+		writeValidationError(w, "invalid pull_request_id (must be UUID)")
+		return
+	}
+
+	var req PushRevisionRequest
+	if r.ContentLength != 0 {
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeValidationError(w, "invalid request body")
+			return
+		}
+	}
+	if req.CommitSHA != nil && *req.CommitSHA == "" {
+		req.CommitSHA = nil
+	}
+
+	pr, revision, err := h.Service.PushRevision(prID, req.CommitSHA)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(map[string]interface{}{
+		"pr":       pr,
+		"revision": revision,
+	})
 	if err != nil {
 		return
 	}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/service"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// RepositoryHandler обрабатывает HTTP запросы, связанные с репозиториями.
+type RepositoryHandler struct {
+	Service *service.RepositoryService
+}
+
+func (h *RepositoryHandler) CreateRepository(w http.ResponseWriter, r *http.Request) {
+	var repo model.Repository
+	if err := json.NewDecoder(r.Body).Decode(&repo); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+
+	created, err := h.Service.CreateRepository(&repo)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	err = json.NewEncoder(w).Encode(created)
+	if err != nil {
+		return
+	}
+}
+
+func (h *RepositoryHandler) GetRepositories(w http.ResponseWriter, r *http.Request) {
+	repos, err := h.Service.GetAllRepositories()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(repos)
+	if err != nil {
+		return
+	}
+}
+
+func (h *RepositoryHandler) GetRepository(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["repository_id"])
+	if err != nil {
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+
+	repo, err := h.Service.GetRepositoryByID(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(repo)
+	if err != nil {
+		return
+	}
+}
+
+func (h *RepositoryHandler) UpdateRepository(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["repository_id"])
+	if err != nil {
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+
+	var repo model.Repository
+	if err = json.NewDecoder(r.Body).Decode(&repo); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+	repo.ID = id
+
+	updated, err := h.Service.UpdateRepository(&repo)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(updated)
+	if err != nil {
+		return
+	}
+}
+
+func (h *RepositoryHandler) DeleteRepository(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["repository_id"])
+	if err != nil {
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+
+	err = h.Service.DeleteRepository(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/service"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// SnapshotHandler обрабатывает HTTP запросы выгрузки и загрузки данных (администрирование).
+type SnapshotHandler struct {
+	Service *service.SnapshotService
+}
+
+// snapshotContentTypes — Content-Type выгрузки по формату.
+var snapshotContentTypes = map[model.SnapshotFormat]string{
+	model.SnapshotJSON: "application/json",
+	model.SnapshotCSV:  "text/csv; charset=utf-8",
+}
+
+// Export отдает выгрузку потоком. Если ошибка возникла после начала ответа,
+// статус уже не изменить: ошибка только логируется, а ответ обрывается.
+func (h *SnapshotHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format := snapshotFormat(r)
+	if !format.IsValid() {
+		writeError(w, service.ErrInvalidSnapshotFormat)
+		return
+	}
+
+	w.Header().Set("Content-Type", snapshotContentTypes[format])
+	w.Header().Set("Content-Disposition", `attachment; filename="snapshot.`+string(format)+`"`)
+	out := &trackingWriter{ResponseWriter: w}
+	err := h.Service.Export(out, format)
+	if err == nil {
+		return
+	}
+	if !out.written {
+		w.Header().Del("Content-Disposition")
+		writeError(w, err)
+		return
+	}
+	log.Printf("snapshot export interrupted: %v", err)
+}
+
+// Import загружает выгрузку из тела запроса. Формат задается параметром format (json по умолчанию),
+// политика для уже существующих записей — параметром on_conflict (fail по умолчанию).
+func (h *SnapshotHandler) Import(w http.ResponseWriter, r *http.Request) {
+	policy := model.ImportPolicy(r.URL.Query().Get("on_conflict"))
+	if policy == "" {
+		policy = model.ImportFail
+	}
+
+	report, err := h.Service.Import(r.Body, snapshotFormat(r), policy)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(report)
+	if err != nil {
+		return
+	}
+}
+
+func snapshotFormat(r *http.Request) model.SnapshotFormat {
+	format := model.SnapshotFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		return model.SnapshotJSON
+	}
+	return format
+}
+
+// trackingWriter запоминает, начата ли запись ответа.
+type trackingWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	t.written = true
+	return t.ResponseWriter.Write(p)
+}
@@ -13,7 +13,7 @@ type StatisticsHandler struct {
 func (h *StatisticsHandler) GetStatistics(w http.ResponseWriter, r *http.Request) {
 	stats, err := h.Service.GetReviewStats()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -4,6 +4,7 @@ import (
 	"avito-assignment/internal/model"
 	"avito-assignment/internal/service"
 	"encoding/json"
+	"io"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -15,20 +16,32 @@ type TeamHandler struct {
 	PRService *service.PRService
 }
 
+// UpdateShadowingRequest представляет настройки наставничества (теневых ревьюверов) команды.
+type UpdateShadowingRequest struct {
+	Enabled bool        `json:"shadowing_enabled"`
+	UserIDs []uuid.UUID `json:"shadow_user_ids"`
+}
+
+// UpdateReviewerTiersRequest представляет уровни числа ревьюверов команды по размеру и риску PR.
+type UpdateReviewerTiersRequest struct {
+	Tiers []model.ReviewerTier `json:"reviewer_tiers"`
+}
+
+// UpdateReviewPolicyRequest представляет политику ревью команды.
+type UpdateReviewPolicyRequest struct {
+	ResetApprovalsOnRevision bool `json:"reset_approvals_on_revision"`
+}
+
 func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 	var team model.Team
 	if err := json.NewDecoder(r.Body).Decode(&team); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeValidationError(w, "invalid request body")
 		return
 	}
 
 	createdTeam, err := h.Service.CreateTeam(&team)
 	if err != nil {
-		if err.Error() == "team with this name already exists" {
-			http.Error(w, err.Error(), http.StatusConflict)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -42,21 +55,78 @@ func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// UpsertTeam создает или обновляет команду вместе с составом участников и возвращает примененный diff.
+func (h *TeamHandler) UpsertTeam(w http.ResponseWriter, r *http.Request) {
+	expectedVersion, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.TeamUpsertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+
+	result, err := h.Service.UpsertTeam(req, expectedVersion, h.PRService)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if result.Diff.TeamCreated {
+		status = http.StatusCreated
+	}
+	setETag(w, result.Team.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		return
+	}
+}
+
+// ListTeams возвращает страницу команд с поиском по началу названия и числом участников.
+func (h *TeamHandler) ListTeams(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := model.TeamListFilter{Search: query.Get("search")}
+
+	var err error
+	if filter.Limit, err = queryInt(query, "limit"); err != nil {
+		writeValidationError(w, err.Error())
+		return
+	}
+
+	page, err := h.Service.ListTeams(filter, query.Get("cursor"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(page)
+	if err != nil {
+		return
+	}
+}
+
 func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["team_id"]
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, "invalid UUID", http.StatusBadRequest)
+		writeValidationError(w, "invalid UUID")
 		return
 	}
 
 	team, err := h.Service.GetTeamByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, err)
 		return
 	}
 
+	setETag(w, team.Version)
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(team)
 	if err != nil {
@@ -69,31 +139,60 @@ func (h *TeamHandler) UpdateTeam(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["team_id"]
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, "invalid UUID", http.StatusBadRequest)
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+	expectedVersion, ok := requireIfMatch(w, r)
+	if !ok {
 		return
 	}
 
 	var team model.Team
 	if err = json.NewDecoder(r.Body).Decode(&team); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeValidationError(w, "invalid request body")
 		return
 	}
 	team.ID = id
 
-	updatedTeam, err := h.Service.UpdateTeam(&team)
+	updatedTeam, err := h.Service.UpdateTeam(&team, expectedVersion)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setETag(w, updatedTeam.Version)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(updatedTeam)
+	if err != nil {
+		return
+	}
+}
+
+// PatchTeam частично обновляет команду по JSON Merge Patch (RFC 7396).
+func (h *TeamHandler) PatchTeam(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["team_id"])
+	if err != nil {
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+	expectedVersion, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
 	if err != nil {
-		if err.Error() == "team not found" {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		if err.Error() == "team with this name already exists" {
-			http.Error(w, err.Error(), http.StatusConflict)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeValidationError(w, "invalid request body")
 		return
 	}
 
+	updatedTeam, err := h.Service.PatchTeam(id, patch, expectedVersion)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setETag(w, updatedTeam.Version)
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(updatedTeam)
 	if err != nil {
@@ -106,13 +205,13 @@ func (h *TeamHandler) DeleteTeam(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["team_id"]
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, "invalid UUID", http.StatusBadRequest)
+		writeValidationError(w, "invalid UUID")
 		return
 	}
 
 	err = h.Service.DeleteTeam(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -128,25 +227,143 @@ func (h *TeamHandler) DeactivateTeamMembers(w http.ResponseWriter, r *http.Reque
 	idStr := vars["team_id"]
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, "invalid UUID", http.StatusBadRequest)
+		writeValidationError(w, "invalid UUID")
 		return
 	}
 
-	deactivatedCount, err := h.Service.DeactivateTeamMembers(id, h.PRService)
+	deactivatedCount, report, err := h.Service.DeactivateTeamMembers(id, h.PRService)
 	if err != nil {
-		if err.Error() == "team not found" {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(map[string]interface{}{
 		"deactivated_count": deactivatedCount,
+		"offboarding":       report,
 	})
 	if err != nil {
 		return
 	}
 }
+
+// GetReviewPool возвращает очередь неназначенных ревью команды.
+func (h *TeamHandler) GetReviewPool(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["team_id"])
+	if err != nil {
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+
+	pool, err := h.PRService.GetReviewPool(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(pool)
+	if err != nil {
+		return
+	}
+}
+
+// UpdateShadowing настраивает теневых ревьюверов-джуниоров команды.
+func (h *TeamHandler) UpdateShadowing(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["team_id"])
+	if err != nil {
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+	expectedVersion, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateShadowingRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+
+	team, err := h.Service.UpdateShadowing(id, req.Enabled, req.UserIDs, expectedVersion)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setETag(w, team.Version)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(team)
+	if err != nil {
+		return
+	}
+}
+
+// UpdateReviewerTiers задает, сколько ревьюверов (и сколько из них senior) нужно PR разного размера.
+func (h *TeamHandler) UpdateReviewerTiers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["team_id"])
+	if err != nil {
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+	expectedVersion, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateReviewerTiersRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+
+	team, err := h.Service.UpdateReviewerTiers(id, req.Tiers, expectedVersion)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setETag(w, team.Version)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(team)
+	if err != nil {
+		return
+	}
+}
+
+// UpdateReviewPolicy задает, сбрасываются ли одобрения PR команды при новой ревизии.
+func (h *TeamHandler) UpdateReviewPolicy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["team_id"])
+	if err != nil {
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+	expectedVersion, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateReviewPolicyRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+
+	team, err := h.Service.UpdateReviewPolicy(id, req.ResetApprovalsOnRevision, expectedVersion)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setETag(w, team.Version)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(team)
+	if err != nil {
+		return
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"avito-assignment/internal/model"
 	"avito-assignment/internal/service"
 	"encoding/json"
+	"io"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -14,16 +15,23 @@ type UserHandler struct {
 	Service *service.UserService
 }
 
+// UpdateUserResponse представляет ответ на обновление пользователя. Offboarding
+// заполняется, если пользователь был снят с открытых ревью.
+type UpdateUserResponse struct {
+	*model.User
+	Offboarding *model.OffboardingReport `json:"offboarding,omitempty"`
+}
+
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var user model.User
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeValidationError(w, "invalid request body")
 		return
 	}
 
 	createdUser, err := h.Service.CreateUser(&user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -35,21 +43,55 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ListUsers возвращает страницу пользователей с поиском по началу имени
+// и фильтрами по команде и активности.
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := model.UserListFilter{Search: query.Get("search")}
+
+	var err error
+	if filter.TeamID, err = queryUUID(query, "team_id"); err != nil {
+		writeValidationError(w, err.Error())
+		return
+	}
+	if filter.IsActive, err = queryBool(query, "is_active"); err != nil {
+		writeValidationError(w, err.Error())
+		return
+	}
+	if filter.Limit, err = queryInt(query, "limit"); err != nil {
+		writeValidationError(w, err.Error())
+		return
+	}
+
+	page, err := h.Service.ListUsers(filter, query.Get("cursor"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(page)
+	if err != nil {
+		return
+	}
+}
+
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["user_id"]
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, "invalid UUID", http.StatusBadRequest)
+		writeValidationError(w, "invalid UUID")
 		return
 	}
 
 	user, err := h.Service.GetUserByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, err)
 		return
 	}
 
+	setETag(w, user.Version)
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(user)
 	if err != nil {
@@ -58,20 +100,64 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil {
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+	expectedVersion, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	var user model.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err = json.NewDecoder(r.Body).Decode(&user); err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+	user.ID = id
+
+	updatedUser, report, err := h.Service.UpdateUser(&user, expectedVersion)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
-	updatedUser, err := h.Service.UpdateUser(&user)
+	setETag(w, updatedUser.Version)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(UpdateUserResponse{User: updatedUser, Offboarding: report})
+	if err != nil {
+		return
+	}
+}
+
+// PatchUser частично обновляет пользователя по JSON Merge Patch (RFC 7396).
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil {
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+	expectedVersion, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeValidationError(w, "invalid request body")
+		return
+	}
+
+	updatedUser, report, err := h.Service.PatchUser(id, patch, expectedVersion)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
+	setETag(w, updatedUser.Version)
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(updatedUser)
+	err = json.NewEncoder(w).Encode(UpdateUserResponse{User: updatedUser, Offboarding: report})
 	if err != nil {
 		return
 	}
@@ -82,13 +168,13 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["user_id"]
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, "invalid UUID", http.StatusBadRequest)
+		writeValidationError(w, "invalid UUID")
 		return
 	}
 
 	err = h.Service.DeleteUser(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -100,13 +186,13 @@ func (h *UserHandler) GetUserPRs(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["user_id"]
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, "invalid UUID", http.StatusBadRequest)
+		writeValidationError(w, "invalid UUID")
 		return
 	}
 
 	prs, err := h.Service.GetAssignedPRs(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -116,3 +202,26 @@ func (h *UserHandler) GetUserPRs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// GetReviewQueue возвращает личную очередь ревью пользователя: только ожидающие решения
+// открытые PR, от самых срочных к менее срочным.
+func (h *UserHandler) GetReviewQueue(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["user_id"])
+	if err != nil {
+		writeValidationError(w, "invalid UUID")
+		return
+	}
+
+	queue, err := h.Service.GetReviewQueue(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(queue)
+	if err != nil {
+		return
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"avito-assignment/internal/api/apierror"
+	"avito-assignment/internal/service"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// statusByKind сопоставляет класс доменной ошибки HTTP статусу.
+var statusByKind = map[service.ErrorKind]int{
+	service.KindInvalid:            http.StatusBadRequest,
+	service.KindNotFound:           http.StatusNotFound,
+	service.KindConflict:           http.StatusConflict,
+	service.KindForbidden:          http.StatusForbidden,
+	service.KindUnprocessable:      http.StatusUnprocessableEntity,
+	service.KindPreconditionFailed: http.StatusPreconditionFailed,
+}
+
+// writeError отвечает ошибкой сервисного слоя. Неизвестные ошибки логируются
+// и возвращаются клиенту как 500 INTERNAL без подробностей.
+func writeError(w http.ResponseWriter, err error) {
+	var domainErr *service.Error
+	if errors.As(err, &domainErr) {
+		status, ok := statusByKind[domainErr.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		apierror.Write(w, status, domainErr.Code, domainErr.Message, domainErr.Details)
+		return
+	}
+
+	log.Printf("internal error: %v", err)
+	apierror.Write(w, http.StatusInternalServerError, "INTERNAL", "internal server error", nil)
+}
+
+// writeValidationError отвечает 400 VALIDATION_ERROR на некорректные параметры или тело запроса.
+func writeValidationError(w http.ResponseWriter, message string) {
+	apierror.WriteValidation(w, message)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"avito-assignment/internal/api/apierror"
+	"avito-assignment/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// setETag отдает версию сущности в заголовке ETag: "N".
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// requireIfMatch возвращает версию из заголовка If-Match: "N", W/"N" или * (любая версия).
+// Без заголовка отвечает 428 PRECONDITION_REQUIRED, при неверном значении — 400, и возвращает false.
+func requireIfMatch(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	raw := strings.TrimSpace(r.Header.Get("If-Match"))
+	if raw == "" {
+		apierror.Write(w, http.StatusPreconditionRequired, "PRECONDITION_REQUIRED",
+			"If-Match header with the resource ETag is required", nil)
+		return 0, false
+	}
+	if raw == "*" {
+		return repository.AnyVersion, true
+	}
+
+	unquoted, err := strconv.Unquote(strings.TrimPrefix(raw, "W/"))
+	if err == nil {
+		version, errParse := strconv.ParseInt(unquoted, 10, 64)
+		if errParse == nil && version > 0 {
+			return version, true
+		}
+	}
+	writeValidationError(w, `If-Match must be an ETag like "3" or *`)
+	return 0, false
+}
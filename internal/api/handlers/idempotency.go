@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/service"
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+)
+
+// IdempotencyKeyHeader — заголовок с ключом идемпотентности запроса.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Idempotency оборачивает мутирующие обработчики: запрос с заголовком Idempotency-Key
+// выполняется один раз, повтор с тем же ключом и телом получает сохраненный ответ.
+type Idempotency struct {
+	Service *service.IdempotencyService
+}
+
+// Wrap применяет идемпотентность к обработчику. Запросы без заголовка (и любые запросы,
+// если m равен nil) обрабатываются как обычно. Ответы 5xx не сохраняются: ключ
+// освобождается, и запрос можно повторить.
+func (m *Idempotency) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if m == nil || key == "" {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeValidationError(w, "cannot read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		stored, err := m.Service.Begin(key, r.Method, r.URL.Path, body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if stored != nil {
+			w.Header().Set("Content-Type", stored.ContentType)
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			_, err = w.Write(stored.Body)
+			if err != nil {
+				return
+			}
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		if rec.status >= http.StatusInternalServerError {
+			if err = m.Service.Release(key, r.Method, r.URL.Path); err != nil {
+				log.Printf("failed to release idempotency key: %v", err)
+			}
+			return
+		}
+		err = m.Service.Complete(&model.IdempotencyRecord{
+			Key:         key,
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			StatusCode:  rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		})
+		if err != nil {
+			log.Printf("failed to store idempotent response: %v", err)
+			// Без сохраненного ответа ключ висел бы в обработке до истечения TTL
+			if err = m.Service.Release(key, r.Method, r.URL.Path); err != nil {
+				log.Printf("failed to release idempotency key: %v", err)
+			}
+		}
+	}
+}
+
+// responseRecorder передает ответ клиенту и запоминает статус и тело для сохранения.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// queryUUID возвращает UUID из параметра запроса или nil, если параметр не задан.
+func queryUUID(query url.Values, name string) (*uuid.UUID, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a UUID", name)
+	}
+	return &id, nil
+}
+
+// queryTime возвращает время в формате RFC 3339 из параметра запроса или nil, если параметр не задан.
+func queryTime(query url.Values, name string) (*time.Time, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 date-time", name)
+	}
+	return &t, nil
+}
+
+// queryInt возвращает целое число из параметра запроса или 0, если параметр не задан.
+func queryInt(query url.Values, name string) (int, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", name)
+	}
+	return n, nil
+}
+
+// queryDescending разбирает параметр order: desc (по умолчанию) или asc.
+func queryDescending(query url.Values) (bool, error) {
+	switch query.Get("order") {
+	case "", "desc":
+		return true, nil
+	case "asc":
+		return false, nil
+	default:
+		return false, fmt.Errorf("order must be asc or desc")
+	}
+}
+
+// queryBool возвращает логическое значение из параметра запроса или nil, если параметр не задан.
+func queryBool(query url.Values, name string) (*bool, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", name)
+	}
+	return &b, nil
+}
@@ -0,0 +1,120 @@
+package openapi
+
+import (
+	"avito-assignment/internal/api/apierror"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ValidationMiddleware проверяет параметры пути и запроса и JSON-тело запроса по спецификации
+// до того, как запрос попадет в обработчик. Маршруты без операции в спецификации
+// пропускаются без проверки. Ошибки возвращаются как 400 VALIDATION_ERROR.
+func (s *Spec) ValidationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tmpl, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		op := s.Operation(tmpl, r.Method)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		vars := mux.Vars(r)
+		query := r.URL.Query()
+		for _, p := range op.Parameters {
+			var value interface{}
+			switch p.In {
+			case "path":
+				value = vars[p.Name]
+			case "query":
+				raw := query.Get(p.Name)
+				if raw == "" {
+					if p.Required {
+						apierror.WriteValidation(w, p.Name+" is required")
+						return
+					}
+					continue
+				}
+				value = s.queryValue(raw, p.Schema)
+			default:
+				continue
+			}
+			if err = s.Validate(value, p.Schema, p.Name); err != nil {
+				apierror.WriteValidation(w, err.Error())
+				return
+			}
+		}
+
+		if op.RequestBody == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		content, ok := op.RequestBody.Content["application/json"]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			apierror.WriteValidation(w, "cannot read request body")
+			return
+		}
+		// Обработчик читает тело повторно
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		if len(bytes.TrimSpace(body)) == 0 {
+			if op.RequestBody.Required {
+				apierror.WriteValidation(w, "request body is required")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var value interface{}
+		if err = json.Unmarshal(body, &value); err != nil {
+			apierror.WriteValidation(w, "request body must be valid JSON")
+			return
+		}
+		if err = s.Validate(value, content.Schema, ""); err != nil {
+			apierror.WriteValidation(w, err.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// queryValue приводит строковый параметр запроса к типу схемы, чтобы проверить его как JSON-значение.
+// Непреобразуемое значение остается строкой и не пройдет проверку типа.
+func (s *Spec) queryValue(raw string, schema *Schema) interface{} {
+	schema = s.resolve(schema)
+	if schema == nil {
+		return raw
+	}
+	switch schema.Type {
+	case "integer", "number":
+		if num, err := strconv.ParseFloat(raw, 64); err == nil {
+			return num
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
@@ -0,0 +1,95 @@
+// Package openapi публикует OpenAPI-спецификацию сервиса и проверяет запросы по ней.
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+//go:embed openapi.json
+var specJSON []byte
+
+//go:embed docs.html
+var docsHTML []byte
+
+// docsAssets содержит вендоренные файлы Swagger UI (см. swagger-ui/README.md):
+// страница документации не должна зависеть от внешнего CDN.
+//
+//go:embed swagger-ui/swagger-ui-bundle.js swagger-ui/swagger-ui.css
+var docsAssets embed.FS
+
+// docsAssetServer отдает файлы из docsAssets по пути /docs/swagger-ui/{file}.
+var docsAssetServer = http.StripPrefix("/docs/", http.FileServer(http.FS(docsAssets)))
+
+// Spec представляет разобранную OpenAPI-спецификацию (только то, что нужно для валидации).
+type Spec struct {
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+// Operation описывает одну операцию (метод пути) спецификации.
+type Operation struct {
+	Parameters  []Parameter  `json:"parameters"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+// Parameter описывает параметр операции.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody описывает тело запроса операции.
+type RequestBody struct {
+	Required bool `json:"required"`
+	Content  map[string]struct {
+		Schema *Schema `json:"schema"`
+	} `json:"content"`
+}
+
+// Load разбирает встроенную спецификацию.
+func Load() (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(specJSON, &spec); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Operation возвращает операцию по шаблону пути mux и HTTP-методу или nil, если ее нет в спецификации.
+func (s *Spec) Operation(pathTemplate, method string) *Operation {
+	item, ok := s.Paths[pathTemplate]
+	if !ok {
+		return nil
+	}
+	return item[strings.ToLower(method)]
+}
+
+// ServeSpec отдает спецификацию в формате JSON (GET /openapi.json).
+func ServeSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write(specJSON)
+	if err != nil {
+		return
+	}
+}
+
+// ServeDocsAsset отдает встроенный скрипт или стиль Swagger UI (GET /docs/swagger-ui/{file}).
+func ServeDocsAsset(w http.ResponseWriter, r *http.Request) {
+	docsAssetServer.ServeHTTP(w, r)
+}
+
+// ServeDocs отдает страницу Swagger UI (GET /docs).
+func ServeDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err := w.Write(docsHTML)
+	if err != nil {
+		return
+	}
+}
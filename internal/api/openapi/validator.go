@@ -0,0 +1,151 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schema описывает подмножество JSON Schema, которое используется в спецификации сервиса.
+type Schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Format     string             `json:"format"`
+	Enum       []interface{}      `json:"enum"`
+	Required   []string           `json:"required"`
+	Properties map[string]*Schema `json:"properties"`
+	Items      *Schema            `json:"items"`
+	AllOf      []*Schema          `json:"allOf"`
+	Minimum    *float64           `json:"minimum"`
+	Maximum    *float64           `json:"maximum"`
+	MinLength  *int               `json:"minLength"`
+	MinItems   *int               `json:"minItems"`
+}
+
+// resolve возвращает схему, на которую ссылается $ref, или саму схему.
+func (s *Spec) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		schema = s.Components.Schemas[name]
+	}
+	return schema
+}
+
+// Validate проверяет значение, полученное из encoding/json, на соответствие схеме.
+// field — путь к значению для сообщения об ошибке.
+func (s *Spec) Validate(value interface{}, schema *Schema, field string) error {
+	schema = s.resolve(schema)
+	if schema == nil {
+		return nil
+	}
+	for _, sub := range schema.AllOf {
+		if err := s.Validate(value, sub, field); err != nil {
+			return err
+		}
+	}
+	label := field
+	if label == "" {
+		label = "request body"
+	}
+	if value == nil {
+		// null равнозначен отсутствующему полю: так его понимают Go-структуры обработчиков
+		return nil
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an object", label)
+		}
+		for _, name := range schema.Required {
+			if v, ok := obj[name]; !ok || v == nil {
+				return fmt.Errorf("%s is required", joinField(field, name))
+			}
+		}
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			v, ok := obj[name]
+			if !ok {
+				continue
+			}
+			if err := s.Validate(v, schema.Properties[name], joinField(field, name)); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an array", label)
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			return fmt.Errorf("%s must contain at least %d items", label, *schema.MinItems)
+		}
+		for i, item := range items {
+			if err := s.Validate(item, schema.Items, fmt.Sprintf("%s[%d]", field, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", label)
+		}
+		if schema.MinLength != nil && len(str) < *schema.MinLength {
+			return fmt.Errorf("%s must not be empty", label)
+		}
+		if schema.Format == "uuid" {
+			if _, err := uuid.Parse(str); err != nil {
+				return fmt.Errorf("%s must be a UUID", label)
+			}
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return fmt.Errorf("%s must be an RFC 3339 date-time", label)
+			}
+		}
+	case "integer", "number":
+		num, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("%s must be a %s", label, schema.Type)
+		}
+		if schema.Type == "integer" && num != math.Trunc(num) {
+			return fmt.Errorf("%s must be an integer", label)
+		}
+		if schema.Minimum != nil && num < *schema.Minimum {
+			return fmt.Errorf("%s must be >= %v", label, *schema.Minimum)
+		}
+		if schema.Maximum != nil && num > *schema.Maximum {
+			return fmt.Errorf("%s must be <= %v", label, *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", label)
+		}
+	}
+
+	if len(schema.Enum) > 0 {
+		for _, allowed := range schema.Enum {
+			if allowed == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must be one of %v", label, schema.Enum)
+	}
+	return nil
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
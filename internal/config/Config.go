@@ -1,10 +1,16 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"time"
+)
 
 // Config содержит всю конфигурацию приложения.
 type Config struct {
-	DB DBConfig
+	DB          DBConfig
+	Review      ReviewConfig
+	Idempotency IdempotencyConfig
 }
 
 // DBConfig содержит параметры подключения к базе данных PostgreSQL.
@@ -16,6 +22,18 @@ type DBConfig struct {
 	Name     string
 }
 
+// ReviewConfig содержит параметры назначения ревьюверов.
+type ReviewConfig struct {
+	// MaxOpenReviews — максимальное число открытых ревью у одного ревьювера (0 — без ограничения).
+	MaxOpenReviews int
+}
+
+// IdempotencyConfig содержит параметры хранения ключей идемпотентности.
+type IdempotencyConfig struct {
+	// TTL — сколько хранятся ключ Idempotency-Key и ответ на запрос.
+	TTL time.Duration
+}
+
 // LoadConfig загружает конфигурацию из переменных окружения.
 func LoadConfig() *Config {
 	dbConfig := DBConfig{
@@ -26,7 +44,15 @@ func LoadConfig() *Config {
 		Name:     getEnv("POSTGRES_DB", "mydatabase"),
 	}
 
-	return &Config{DB: dbConfig}
+	reviewConfig := ReviewConfig{
+		MaxOpenReviews: getEnvInt("MAX_OPEN_REVIEWS", 0),
+	}
+
+	idempotencyConfig := IdempotencyConfig{
+		TTL: time.Duration(getEnvInt("IDEMPOTENCY_TTL_HOURS", 24)) * time.Hour,
+	}
+
+	return &Config{DB: dbConfig, Review: reviewConfig, Idempotency: idempotencyConfig}
 }
 
 // getEnv получает значение переменной окружения или возвращает значение по умолчанию.
@@ -36,3 +62,12 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt получает целочисленное значение переменной окружения или возвращает значение по умолчанию.
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
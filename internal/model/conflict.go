@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConflictRule запрещает назначать ReviewerID ревьювером PR автора AuthorID.
+// Если Mutual равен true, запрет действует в обе стороны (например, руководитель и подчиненный).
+type ConflictRule struct {
+	ID         uuid.UUID `json:"conflict_id"`
+	ReviewerID uuid.UUID `json:"reviewer_id"`
+	AuthorID   uuid.UUID `json:"author_id"`
+	Mutual     bool      `json:"mutual"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// ConflictExclusion описывает пользователя, исключенного из кандидатов правилом конфликта интересов.
+type ConflictExclusion struct {
+	UserID         uuid.UUID `json:"user_id"`
+	Username       string    `json:"username"`
+	ConflictRuleID uuid.UUID `json:"conflict_id"`
+	Reason         string    `json:"reason"`
+}
+
+// AssignmentExplanation объясняет выбор ревьюверов при создании PR.
+type AssignmentExplanation struct {
+	ConflictExclusions []ConflictExclusion `json:"conflict_exclusions"`
+}
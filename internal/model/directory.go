@@ -0,0 +1,48 @@
+package model
+
+import "github.com/google/uuid"
+
+// UserListFilter описывает фильтры и страницу списка пользователей.
+// Search — начало имени пользователя без учета регистра.
+type UserListFilter struct {
+	Search   string
+	TeamID   *uuid.UUID
+	IsActive *bool
+	Limit    int
+	// After — имя последнего пользователя предыдущей страницы (пусто для первой страницы)
+	After string
+}
+
+// UserPage представляет страницу списка пользователей. NextCursor пуст на последней странице.
+type UserPage struct {
+	Items      []User `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// TeamListFilter описывает фильтры и страницу списка команд.
+// Search — начало названия команды без учета регистра.
+type TeamListFilter struct {
+	Search string
+	Limit  int
+	// After — название последней команды предыдущей страницы (пусто для первой страницы)
+	After string
+}
+
+// TeamSummary представляет команду в списке: без участников, только их число.
+type TeamSummary struct {
+	ID                uuid.UUID `json:"team_id"`
+	Name              string    `json:"team_name"`
+	MemberCount       int       `json:"member_count"`
+	ActiveMemberCount int       `json:"active_member_count"`
+}
+
+// TeamPage представляет страницу списка команд. NextCursor пуст на последней странице.
+type TeamPage struct {
+	Items      []TeamSummary `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// NameCursor — позиция в списке, отсортированном по уникальному имени.
+type NameCursor struct {
+	Name string `json:"n"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PREventType описывает тип события по Pull Request.
+type PREventType string
+
+const (
+	// EventPRUnblocked — все зависимости PR смержены, его можно мержить.
+	EventPRUnblocked PREventType = "PR_UNBLOCKED"
+	// EventReviewApproved — ревьювер одобрил текущую ревизию PR.
+	EventReviewApproved PREventType = "REVIEW_APPROVED"
+	// EventRevisionPushed — в PR запушена новая ревизия.
+	EventRevisionPushed PREventType = "PR_REVISION_PUSHED"
+	// EventReviewRerequested — ревьюверу нужно посмотреть новую ревизию заново.
+	EventReviewRerequested PREventType = "REVIEW_REREQUESTED"
+)
+
+// PREvent представляет событие (уведомление) по Pull Request.
+type PREvent struct {
+	ID        uuid.UUID       `json:"event_id"`
+	PRID      uuid.UUID       `json:"pull_request_id"`
+	Type      PREventType     `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// IdempotencyRecord представляет запрос с ключом идемпотентности и сохраненный ответ на него.
+// StatusCode равен 0, пока первый запрос с этим ключом еще обрабатывается.
+type IdempotencyRecord struct {
+	Key         string
+	Method      string
+	Path        string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time
+}
@@ -12,6 +12,9 @@ type User struct {
 	ID       uuid.UUID `json:"user_id,omitempty"`
 	TeamID   uuid.UUID `json:"team_id"`
 	IsActive bool      `json:"is_active"`
+	IsSenior bool      `json:"is_senior"`
+	// Version растет при каждом изменении и отдается клиенту как ETag
+	Version int64 `json:"version"`
 }
 
 // Team представляет команду пользователей.
@@ -19,6 +22,14 @@ type Team struct {
 	Name    string    `json:"team_name"`
 	Members []User    `json:"members,omitempty"`
 	ID      uuid.UUID `json:"team_id"`
+	// ShadowingEnabled включает назначение теневых ревьюверов из ShadowUserIDs
+	ShadowingEnabled bool           `json:"shadowing_enabled"`
+	ShadowUserIDs    []uuid.UUID    `json:"shadow_user_ids,omitempty"`
+	ReviewerTiers    []ReviewerTier `json:"reviewer_tiers,omitempty"`
+	// ResetApprovalsOnRevision сбрасывает одобрения PR команды при каждой новой ревизии
+	ResetApprovalsOnRevision bool `json:"reset_approvals_on_revision"`
+	// Version растет при каждом изменении и отдается клиенту как ETag
+	Version int64 `json:"version"`
 }
 
 // PRStatus описывает статус Pull Request.
@@ -31,11 +42,137 @@ const (
 
 // PullRequest представляет Pull Request с назначенными ревьюверами.
 type PullRequest struct {
-	Title     string      `json:"pull_request_name"`
-	ID        uuid.UUID   `json:"pull_request_id"`
-	AuthorID  uuid.UUID   `json:"author_id"`
-	Reviewers []uuid.UUID `json:"reviewers"`
-	Status    PRStatus    `json:"status"`
-	CreatedAt time.Time   `json:"createdAt"`
-	MergedAt  *time.Time  `json:"mergedAt,omitempty"`
+	Title             string      `json:"pull_request_name"`
+	ID                uuid.UUID   `json:"pull_request_id"`
+	ExternalID        *string     `json:"external_id,omitempty"`
+	AuthorID          uuid.UUID   `json:"author_id"`
+	RepositoryID      uuid.UUID   `json:"repository_id"`
+	Reviewers         []uuid.UUID `json:"reviewers"`
+	OptionalReviewers []uuid.UUID `json:"optional_reviewers,omitempty"`
+	ShadowReviewers   []uuid.UUID `json:"shadow_reviewers,omitempty"`
+	RequiredReviewers int         `json:"required_reviewers"`
+	ReviewTeamIDs     []uuid.UUID `json:"review_team_ids,omitempty"`
+	// TargetTeamIDs — команды кросс-командного PR, от каждой из которых нужно одобрение
+	TargetTeamIDs []uuid.UUID `json:"target_team_ids,omitempty"`
+	DependsOn     []uuid.UUID `json:"depends_on,omitempty"`
+	DiffStats     DiffStats   `json:"diff_stats"`
+	Priority      PRPriority  `json:"priority"`
+	// ReviewDueAt — срок ревью по SLA (nil, если SLA не задан)
+	ReviewDueAt *time.Time `json:"review_due_at,omitempty"`
+	// Labels — метки PR (например, "backend", "hotfix"), по ним фильтруется список PR
+	Labels     []string    `json:"labels,omitempty"`
+	Revision   int         `json:"revision"`
+	ApprovedBy []uuid.UUID `json:"approved_by,omitempty"`
+	Status     PRStatus    `json:"status"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	MergedAt   *time.Time  `json:"mergedAt,omitempty"`
+	// Version растет при каждом изменении PR и отдается клиенту как ETag
+	Version int64 `json:"version"`
+}
+
+// PRPriority описывает приоритет Pull Request.
+type PRPriority string
+
+const (
+	PriorityLow      PRPriority = "LOW"
+	PriorityNormal   PRPriority = "NORMAL"
+	PriorityHigh     PRPriority = "HIGH"
+	PriorityCritical PRPriority = "CRITICAL"
+)
+
+// DiffStats описывает размер и риск изменений PR.
+type DiffStats struct {
+	LinesAdded   int  `json:"lines_added"`
+	LinesRemoved int  `json:"lines_removed"`
+	FilesChanged int  `json:"files_changed"`
+	HighRisk     bool `json:"high_risk"`
+}
+
+// TotalLines возвращает общий размер изменений (добавлено + удалено).
+func (d DiffStats) TotalLines() int {
+	return d.LinesAdded + d.LinesRemoved
+}
+
+// ReviewerTier задает число ревьюверов для PR команды начиная с размера MinLines.
+// Уровень с HighRisk применяется только к рискованным PR.
+type ReviewerTier struct {
+	MinLines        int  `json:"min_lines"`
+	HighRisk        bool `json:"high_risk"`
+	Reviewers       int  `json:"reviewers"`
+	SeniorReviewers int  `json:"senior_reviewers"`
+}
+
+// ReviewerRole описывает роль ревьювера в PR.
+type ReviewerRole string
+
+const (
+	// RoleRequired — обязательный ревьювер, учитывается в политике мержа.
+	RoleRequired ReviewerRole = "REQUIRED"
+	// RoleOptional — опциональный ревьювер (наблюдатель), ничего не блокирует.
+	RoleOptional ReviewerRole = "OPTIONAL"
+	// RoleShadow — теневой ревьювер-джуниор (наставничество), ничего не блокирует.
+	RoleShadow ReviewerRole = "SHADOW"
+)
+
+// ReviewDecision описывает решение ревьювера по текущей ревизии PR.
+type ReviewDecision string
+
+const (
+	DecisionPending  ReviewDecision = "PENDING"
+	DecisionApproved ReviewDecision = "APPROVED"
+)
+
+// PRRevision представляет новую ревизию (пуш) PR.
+type PRRevision struct {
+	ID        uuid.UUID `json:"revision_id"`
+	PRID      uuid.UUID `json:"pull_request_id"`
+	Number    int       `json:"number"`
+	CommitSHA *string   `json:"commit_sha,omitempty"`
+	// ApprovalsReset равен true, если по политике команды одобрения сброшены в PENDING
+	ApprovalsReset bool `json:"approvals_reset"`
+	// RereviewRequested — ревьюверы, которым нужно посмотреть PR заново
+	RereviewRequested []uuid.UUID `json:"rereview_requested"`
+	CreatedAt         time.Time   `json:"createdAt"`
+}
+
+// ReviewPoolEntry представляет PR в очереди неназначенных ревью команды.
+type ReviewPoolEntry struct {
+	PullRequest
+	OpenSlots int `json:"open_slots"`
+}
+
+// ReviewerChangeKind описывает причину замены ревьювера.
+type ReviewerChangeKind string
+
+const (
+	// ReviewerReassigned — ревьювер заменен через переназначение (администратором).
+	ReviewerReassigned ReviewerChangeKind = "REASSIGN"
+	// ReviewerDeclined — ревьювер сам отказался от ревью и исключен из PR навсегда.
+	ReviewerDeclined ReviewerChangeKind = "DECLINE"
+	// ReviewerOffboarded — ревьювер снят с ревью из-за деактивации или перехода в другую команду.
+	ReviewerOffboarded ReviewerChangeKind = "OFFBOARD"
+)
+
+// ReviewerPreferences описывает пожелания автора по ревьюверам при создании PR.
+type ReviewerPreferences struct {
+	Requested []uuid.UUID
+	Excluded  []uuid.UUID
+}
+
+// RejectionReason описывает, почему запрошенный ревьювер не был назначен.
+type RejectionReason string
+
+const (
+	RejectedInactive   RejectionReason = "inactive"
+	RejectedAuthor     RejectionReason = "author"
+	RejectedAtCapacity RejectionReason = "at_capacity"
+	RejectedAbsent     RejectionReason = "absent"
+	RejectedExcluded   RejectionReason = "excluded"
+	RejectedConflict   RejectionReason = "conflict_of_interest"
+)
+
+// RejectedReviewer представляет запрошенного ревьювера, которого нельзя назначить.
+type RejectedReviewer struct {
+	UserID uuid.UUID       `json:"user_id"`
+	Reason RejectionReason `json:"reason"`
 }
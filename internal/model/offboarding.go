@@ -0,0 +1,24 @@
+package model
+
+import "github.com/google/uuid"
+
+// ReviewerReplacement описывает замену ревьювера в PR. Role — роль снятого ревьювера.
+// NewReviewerID равен nil, если замены не нашлось и PR вернулся в очередь ревью,
+// а также для опциональных и теневых ревьюверов, которые снимаются без замены.
+type ReviewerReplacement struct {
+	PRID          uuid.UUID    `json:"pull_request_id"`
+	OldReviewerID uuid.UUID    `json:"old_reviewer_id"`
+	Role          ReviewerRole `json:"role"`
+	NewReviewerID *uuid.UUID   `json:"new_reviewer_id"`
+}
+
+// OffboardingReport описывает результат снятия пользователей с открытых ревью
+// при деактивации или переходе в другую команду. Removed — число снятых опциональных
+// и теневых ревьюверов.
+type OffboardingReport struct {
+	UserIDs        []uuid.UUID           `json:"user_ids"`
+	Reassigned     int                   `json:"reassigned"`
+	ReturnedToPool int                   `json:"returned_to_pool"`
+	Removed        int                   `json:"removed"`
+	Replacements   []ReviewerReplacement `json:"replacements"`
+}
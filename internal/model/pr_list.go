@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PRSortField описывает поле сортировки списка PR.
+type PRSortField string
+
+const (
+	SortByCreatedAt PRSortField = "created_at"
+	SortByPriority  PRSortField = "priority"
+	SortByName      PRSortField = "pull_request_name"
+)
+
+// PRListFilter описывает фильтры, сортировку и страницу списка PR.
+// Пустые поля фильтра не ограничивают выборку.
+type PRListFilter struct {
+	Status       *PRStatus
+	AuthorID     *uuid.UUID
+	ReviewerID   *uuid.UUID
+	TeamID       *uuid.UUID
+	RepositoryID *uuid.UUID
+	Label        string
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	MergedFrom   *time.Time
+	MergedTo     *time.Time
+
+	Sort       PRSortField
+	Descending bool
+	Limit      int
+	// After — позиция последнего PR предыдущей страницы (nil для первой страницы)
+	After *PRCursor
+}
+
+// PRCursor — позиция в списке PR: значение поля сортировки и ID последнего PR страницы.
+// Сортировка сохраняется в курсоре, чтобы курсор нельзя было применить к другому порядку.
+type PRCursor struct {
+	Sort       PRSortField `json:"s"`
+	Descending bool        `json:"d"`
+	Value      string      `json:"v"`
+	ID         uuid.UUID   `json:"id"`
+}
+
+// PRPage представляет страницу списка PR. NextCursor пуст на последней странице.
+type PRPage struct {
+	Items      []PullRequest `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import "github.com/google/uuid"
+
+// AssignmentStrategy описывает способ выбора ревьюверов.
+type AssignmentStrategy string
+
+const (
+	// StrategyRandom — случайный выбор из кандидатов.
+	StrategyRandom AssignmentStrategy = "random"
+	// StrategyLeastLoaded — выбор кандидатов с наименьшим числом открытых ревью.
+	StrategyLeastLoaded AssignmentStrategy = "least_loaded"
+)
+
+// Repository представляет репозиторий (кодовую базу) с командами-владельцами
+// и собственными настройками ревью.
+type Repository struct {
+	Name               string             `json:"repository_name"`
+	ID                 uuid.UUID          `json:"repository_id"`
+	OwnerTeamIDs       []uuid.UUID        `json:"owner_team_ids"`
+	RequiredReviewers  int                `json:"required_reviewers"`
+	AssignmentStrategy AssignmentStrategy `json:"assignment_strategy"`
+}
+
+// IsValid проверяет, что стратегия назначения поддерживается.
+func (s AssignmentStrategy) IsValid() bool {
+	return s == StrategyRandom || s == StrategyLeastLoaded
+}
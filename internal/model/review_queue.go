@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewQueueEntry представляет ожидающее ревью в личной очереди пользователя.
+type ReviewQueueEntry struct {
+	PRID           uuid.UUID    `json:"pull_request_id"`
+	Title          string       `json:"pull_request_name"`
+	AuthorID       uuid.UUID    `json:"author_id"`
+	AuthorUsername string       `json:"author_username"`
+	Role           ReviewerRole `json:"role"`
+	Priority       PRPriority   `json:"priority"`
+	CreatedAt      time.Time    `json:"createdAt"`
+	ReviewDueAt    *time.Time   `json:"review_due_at,omitempty"`
+	// SLARemainingHours отрицательно, если срок ревью уже прошел
+	SLARemainingHours *float64 `json:"sla_remaining_hours,omitempty"`
+	// AuthorBlocked равен true, если у автора есть открытые PR, зависящие от этого
+	AuthorBlocked  bool            `json:"author_blocked"`
+	OtherReviewers []ReviewerState `json:"other_reviewers"`
+	Score          float64         `json:"urgency_score"`
+}
+
+// ReviewerState представляет ревьювера PR и его текущее решение.
+type ReviewerState struct {
+	UserID   uuid.UUID      `json:"user_id"`
+	Username string         `json:"username"`
+	Role     ReviewerRole   `json:"role"`
+	Decision ReviewDecision `json:"decision"`
+}
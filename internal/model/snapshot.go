@@ -0,0 +1,245 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SnapshotSection — раздел выгрузки данных.
+type SnapshotSection string
+
+const (
+	SectionTeams             SnapshotSection = "teams"
+	SectionRepositories      SnapshotSection = "repositories"
+	SectionUsers             SnapshotSection = "users"
+	SectionShadowReviewers   SnapshotSection = "shadow_reviewers"
+	SectionReviewerTiers     SnapshotSection = "reviewer_tiers"
+	SectionReviewerConflicts SnapshotSection = "reviewer_conflicts"
+	SectionPullRequests      SnapshotSection = "pull_requests"
+	SectionReviewTeams       SnapshotSection = "review_teams"
+	SectionDependencies      SnapshotSection = "dependencies"
+	SectionExcludedReviewers SnapshotSection = "excluded_reviewers"
+	SectionAssignments       SnapshotSection = "reviewer_assignments"
+	SectionRevisions         SnapshotSection = "revisions"
+	SectionReviewerChanges   SnapshotSection = "reviewer_changes"
+	SectionEvents            SnapshotSection = "events"
+)
+
+// SnapshotSections перечисляет разделы в порядке зависимостей: каждый раздел ссылается
+// только на предыдущие, поэтому в этом же порядке они и загружаются.
+var SnapshotSections = []SnapshotSection{
+	SectionTeams, SectionRepositories, SectionUsers, SectionShadowReviewers, SectionReviewerTiers,
+	SectionReviewerConflicts, SectionPullRequests, SectionReviewTeams, SectionDependencies,
+	SectionExcludedReviewers, SectionAssignments, SectionRevisions, SectionReviewerChanges, SectionEvents,
+}
+
+// SnapshotTeam — команда в выгрузке.
+type SnapshotTeam struct {
+	ID                       uuid.UUID `json:"team_id"`
+	Name                     string    `json:"team_name"`
+	ShadowingEnabled         bool      `json:"shadowing_enabled"`
+	ResetApprovalsOnRevision bool      `json:"reset_approvals_on_revision"`
+	CreatedAt                time.Time `json:"created_at"`
+}
+
+// SnapshotRepository — репозиторий в выгрузке вместе с командами-владельцами.
+type SnapshotRepository struct {
+	ID                 uuid.UUID          `json:"repository_id"`
+	Name               string             `json:"repository_name"`
+	RequiredReviewers  int                `json:"required_reviewers"`
+	AssignmentStrategy AssignmentStrategy `json:"assignment_strategy"`
+	OwnerTeamIDs       []uuid.UUID        `json:"owner_team_ids"`
+	CreatedAt          time.Time          `json:"created_at"`
+}
+
+// SnapshotUser — пользователь в выгрузке. TeamID равен nil, если пользователь не в команде.
+type SnapshotUser struct {
+	ID        uuid.UUID  `json:"user_id"`
+	Username  string     `json:"username"`
+	TeamID    *uuid.UUID `json:"team_id"`
+	IsActive  bool       `json:"is_active"`
+	IsSenior  bool       `json:"is_senior"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// SnapshotShadowReviewer — джуниор команды, назначаемый теневым ревьювером, в выгрузке.
+// LastAssignedAt равен nil, если джуниор еще не назначался.
+type SnapshotShadowReviewer struct {
+	TeamID         uuid.UUID  `json:"team_id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	LastAssignedAt *time.Time `json:"last_assigned_at"`
+}
+
+// SnapshotReviewerTier — уровень числа ревьюверов команды в выгрузке.
+type SnapshotReviewerTier struct {
+	TeamID          uuid.UUID `json:"team_id"`
+	MinLines        int       `json:"min_lines"`
+	HighRisk        bool      `json:"high_risk"`
+	Reviewers       int       `json:"reviewers"`
+	SeniorReviewers int       `json:"senior_reviewers"`
+}
+
+// SnapshotReviewerConflict — правило конфликта интересов в выгрузке.
+type SnapshotReviewerConflict struct {
+	ID         uuid.UUID `json:"conflict_id"`
+	ReviewerID uuid.UUID `json:"reviewer_id"`
+	AuthorID   uuid.UUID `json:"author_id"`
+	Mutual     bool      `json:"mutual"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SnapshotPullRequest — PR в выгрузке без ревьюверов (они выгружаются отдельным разделом).
+type SnapshotPullRequest struct {
+	ID                uuid.UUID  `json:"pull_request_id"`
+	ExternalID        *string    `json:"external_id"`
+	Title             string     `json:"pull_request_name"`
+	AuthorID          uuid.UUID  `json:"author_id"`
+	RepositoryID      uuid.UUID  `json:"repository_id"`
+	RequiredReviewers int        `json:"required_reviewers"`
+	LinesAdded        int        `json:"lines_added"`
+	LinesRemoved      int        `json:"lines_removed"`
+	FilesChanged      int        `json:"files_changed"`
+	HighRisk          bool       `json:"high_risk"`
+	Priority          PRPriority `json:"priority"`
+	ReviewDueAt       *time.Time `json:"review_due_at"`
+	Labels            []string   `json:"labels"`
+	Status            PRStatus   `json:"status"`
+	CreatedAt         time.Time  `json:"created_at"`
+	MergedAt          *time.Time `json:"merged_at"`
+}
+
+// SnapshotAssignment — назначение ревьювера на PR в выгрузке.
+type SnapshotAssignment struct {
+	PRID       uuid.UUID      `json:"pull_request_id"`
+	ReviewerID uuid.UUID      `json:"reviewer_id"`
+	Role       ReviewerRole   `json:"role"`
+	Decision   ReviewDecision `json:"decision"`
+	AssignedAt time.Time      `json:"assigned_at"`
+	DecidedAt  *time.Time     `json:"decided_at"`
+}
+
+// SnapshotReviewTeam — команда, из которой PR набирает ревьюверов, в выгрузке.
+// ApprovalRequired равен true для целевых команд кросс-командного PR.
+type SnapshotReviewTeam struct {
+	PRID             uuid.UUID `json:"pull_request_id"`
+	TeamID           uuid.UUID `json:"team_id"`
+	ApprovalRequired bool      `json:"approval_required"`
+}
+
+// SnapshotDependency — зависимость PR от другого PR в выгрузке.
+type SnapshotDependency struct {
+	PRID        uuid.UUID `json:"pull_request_id"`
+	DependsOnID uuid.UUID `json:"depends_on_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SnapshotExcludedReviewer — пользователь, исключенный из ревьюверов PR, в выгрузке.
+type SnapshotExcludedReviewer struct {
+	PRID   uuid.UUID `json:"pull_request_id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// SnapshotRevision — ревизия PR в выгрузке.
+type SnapshotRevision struct {
+	ID             uuid.UUID `json:"revision_id"`
+	PRID           uuid.UUID `json:"pull_request_id"`
+	Number         int       `json:"number"`
+	CommitSHA      *string   `json:"commit_sha"`
+	ApprovalsReset bool      `json:"approvals_reset"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// SnapshotReviewerChange — замена или снятие ревьювера PR в выгрузке.
+// NewReviewerID равен nil, если замены не нашлось.
+type SnapshotReviewerChange struct {
+	ID            uuid.UUID          `json:"change_id"`
+	PRID          uuid.UUID          `json:"pull_request_id"`
+	OldReviewerID uuid.UUID          `json:"old_reviewer_id"`
+	NewReviewerID *uuid.UUID         `json:"new_reviewer_id"`
+	Kind          ReviewerChangeKind `json:"kind"`
+	Reason        string             `json:"reason"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
+// SnapshotEvent — событие PR в выгрузке.
+type SnapshotEvent struct {
+	ID        uuid.UUID       `json:"event_id"`
+	PRID      uuid.UUID       `json:"pull_request_id"`
+	Type      PREventType     `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// SnapshotFormat — формат файла выгрузки.
+type SnapshotFormat string
+
+const (
+	// SnapshotJSON — JSON-объект, в котором каждому разделу соответствует массив записей.
+	SnapshotJSON SnapshotFormat = "json"
+	// SnapshotCSV — CSV, в котором перед записями каждого раздела идет строка заголовка "#раздел,колонки...",
+	// а первая колонка записи — имя раздела.
+	SnapshotCSV SnapshotFormat = "csv"
+)
+
+// IsValid проверяет, что формат выгрузки поддерживается.
+func (f SnapshotFormat) IsValid() bool {
+	return f == SnapshotJSON || f == SnapshotCSV
+}
+
+// ImportPolicy определяет, что делать с записью, которая уже есть в базе.
+type ImportPolicy string
+
+const (
+	// ImportSkip оставляет существующую запись без изменений.
+	ImportSkip ImportPolicy = "skip"
+	// ImportOverwrite заменяет существующую запись записью из выгрузки.
+	ImportOverwrite ImportPolicy = "overwrite"
+	// ImportFail отменяет всю загрузку.
+	ImportFail ImportPolicy = "fail"
+)
+
+// IsValid проверяет, что политика конфликтов поддерживается.
+func (p ImportPolicy) IsValid() bool {
+	switch p {
+	case ImportSkip, ImportOverwrite, ImportFail:
+		return true
+	}
+	return false
+}
+
+// ImportOutcome — результат загрузки одной записи.
+type ImportOutcome int
+
+const (
+	ImportInserted ImportOutcome = iota
+	ImportUpdated
+	ImportSkipped
+)
+
+// ImportCounts — число вставленных, перезаписанных и пропущенных записей раздела.
+type ImportCounts struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Skipped  int `json:"skipped"`
+}
+
+// Add учитывает результат загрузки записи.
+func (c *ImportCounts) Add(outcome ImportOutcome) {
+	switch outcome {
+	case ImportInserted:
+		c.Inserted++
+	case ImportUpdated:
+		c.Updated++
+	case ImportSkipped:
+		c.Skipped++
+	}
+}
+
+// ImportReport — итог загрузки выгрузки по разделам.
+type ImportReport struct {
+	Policy   ImportPolicy                      `json:"on_conflict"`
+	Sections map[SnapshotSection]*ImportCounts `json:"sections"`
+}
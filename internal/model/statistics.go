@@ -3,12 +3,24 @@ package model
 // ReviewStats представляет статистику по назначениям ревьюверов
 type ReviewStats struct {
 	TotalAssignments      int                   `json:"total_assignments"`
+	OptionalAssignments   int                   `json:"optional_assignments"`
+	ShadowAssignments     int                   `json:"shadow_assignments"`
 	AssignmentsByUser     []UserAssignmentStats `json:"assignments_by_user"`
 	AssignmentsByPR       []PRAssignmentStats   `json:"assignments_by_pr"`
 	TotalPRs              int                   `json:"total_prs"`
 	OpenPRs               int                   `json:"open_prs"`
 	MergedPRs             int                   `json:"merged_prs"`
 	AverageReviewersPerPR float64               `json:"average_reviewers_per_pr"`
+	Reassignments         int                   `json:"reassignments"`
+	Declines              int                   `json:"declines"`
+	MergeTimeBySize       []SizeMergeStats      `json:"merge_time_by_size"`
+}
+
+// SizeMergeStats представляет среднее время до мержа для PR одного размера
+type SizeMergeStats struct {
+	Size              string  `json:"size"`
+	MergedPRs         int     `json:"merged_prs"`
+	AverageMergeHours float64 `json:"average_merge_hours"`
 }
 
 // UserAssignmentStats представляет статистику назначений для пользователя
@@ -16,6 +28,9 @@ type UserAssignmentStats struct {
 	UserID      string `json:"user_id"`
 	Username    string `json:"username"`
 	Assignments int    `json:"assignments"`
+	Optional    int    `json:"optional_assignments"`
+	Shadow      int    `json:"shadow_assignments"`
+	Declines    int    `json:"declines"`
 }
 
 // PRAssignmentStats представляет статистику назначений для PR
@@ -23,5 +38,6 @@ type PRAssignmentStats struct {
 	PRID           string `json:"pr_id"`
 	PRTitle        string `json:"pr_title"`
 	ReviewersCount int    `json:"reviewers_count"`
+	OptionalCount  int    `json:"optional_reviewers_count"`
 	Status         string `json:"status"`
 }
@@ -0,0 +1,57 @@
+package model
+
+import "github.com/google/uuid"
+
+// TeamUpsertMember ссылается на участника команды: на существующего пользователя по UserID
+// или Username либо на нового пользователя по Username. Заданные IsActive и IsSenior
+// применяются к пользователю, не заданные сохраняют текущие значения
+// (у нового пользователя — активен, не сеньор).
+type TeamUpsertMember struct {
+	UserID   *uuid.UUID `json:"user_id,omitempty"`
+	Username string     `json:"username,omitempty"`
+	IsActive *bool      `json:"is_active,omitempty"`
+	IsSenior *bool      `json:"is_senior,omitempty"`
+}
+
+// TeamUpsertRequest описывает желаемое состояние команды: команда с таким названием
+// создается, если ее нет, а ее состав приводится ровно к Members.
+type TeamUpsertRequest struct {
+	Name    string             `json:"team_name"`
+	Members []TeamUpsertMember `json:"members"`
+}
+
+// TeamUpsertPlan — изменения, которые upsert команды применяет в одной транзакции.
+// Version пользователей в UpdateUsers и RemoveUsers — версия, на которой строился план.
+// Offboarding — замены ревьюверов, снимающие ушедших и деактивированных участников с открытых PR.
+type TeamUpsertPlan struct {
+	Team        Team
+	CreateTeam  bool
+	CreateUsers []User
+	UpdateUsers []User
+	RemoveUsers []User
+	Offboarding []ReviewerReplacement
+}
+
+// MemberMove описывает участника, перешедшего в команду. FromTeamID равен nil,
+// если до этого пользователь не состоял в команде.
+type MemberMove struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	FromTeamID *uuid.UUID `json:"from_team_id"`
+}
+
+// TeamUpsertDiff описывает изменения, примененные upsert команды.
+type TeamUpsertDiff struct {
+	TeamCreated    bool         `json:"team_created"`
+	CreatedUsers   []uuid.UUID  `json:"created_users"`
+	AddedMembers   []MemberMove `json:"added_members"`
+	RemovedMembers []uuid.UUID  `json:"removed_members"`
+	UpdatedUsers   []uuid.UUID  `json:"updated_users"`
+}
+
+// TeamUpsertResult — итог upsert команды: команда с участниками, примененный diff
+// и отчет о снятии с открытых ревью ушедших и деактивированных участников.
+type TeamUpsertResult struct {
+	Team        *Team              `json:"team"`
+	Diff        TeamUpsertDiff     `json:"diff"`
+	Offboarding *OffboardingReport `json:"offboarding,omitempty"`
+}
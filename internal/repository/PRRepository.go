@@ -6,8 +6,25 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// reviewersByRoleQuery выбирает ревьюверов PR с указанной ролью.
+const reviewersByRoleQuery = `
+	SELECT reviewer_id
+	FROM pr_reviewers
+	WHERE pr_id = $1 AND role = $2
+	ORDER BY assigned_at
+`
+
+// reviewersByRoleBatchQuery выбирает пары (PR, ревьювер) с указанной ролью для списка PR.
+const reviewersByRoleBatchQuery = `
+	SELECT pr_id, reviewer_id
+	FROM pr_reviewers
+	WHERE pr_id = ANY($1::uuid[]) AND role = $2
+	ORDER BY assigned_at
+`
+
 type PRRepository struct {
 	DB *sql.DB
 }
@@ -16,8 +33,9 @@ func NewPRRepository(db *sql.DB) *PRRepository {
 	return &PRRepository{DB: db}
 }
 
-// Create создает PR и назначает ревьюверов
-func (r *PRRepository) Create(pr *model.PullRequest, reviewers []uuid.UUID) error {
+// Create создает PR, назначает ревьюверов и сохраняет исключенных автором пользователей.
+// Очередь ротации теневых ревьюверов сдвигается в команде shadowTeamID, из которой они выбраны.
+func (r *PRRepository) Create(pr *model.PullRequest, reviewers, excluded []uuid.UUID, shadowTeamID uuid.UUID) error {
 	tx, err := r.DB.Begin()
 	if err != nil {
 		return err
@@ -30,10 +48,16 @@ func (r *PRRepository) Create(pr *model.PullRequest, reviewers []uuid.UUID) erro
 	}()
 
 	query := `
-		INSERT INTO pull_requests (id, pull_request_name, author_id, status, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO pull_requests (
+			id, external_id, pull_request_name, author_id, repository_id, required_reviewers,
+			lines_added, lines_removed, files_changed, high_risk, priority, review_due_at, labels, status, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING version
 	`
-	_, err = tx.Exec(query, pr.ID, pr.Title, pr.AuthorID, pr.Status, pr.CreatedAt)
+	err = tx.QueryRow(query, pr.ID, pr.ExternalID, pr.Title, pr.AuthorID, pr.RepositoryID, pr.RequiredReviewers,
+		pr.DiffStats.LinesAdded, pr.DiffStats.LinesRemoved, pr.DiffStats.FilesChanged, pr.DiffStats.HighRisk,
+		pr.Priority, pr.ReviewDueAt, pq.StringArray(pr.Labels), pr.Status, pr.CreatedAt).Scan(&pr.Version)
 	if err != nil {
 		return err
 	}
@@ -49,53 +73,101 @@ func (r *PRRepository) Create(pr *model.PullRequest, reviewers []uuid.UUID) erro
 		}
 	}
 
+	// Теневые ревьюверы: назначение и сдвиг очереди ротации джуниоров
+	for _, shadowID := range pr.ShadowReviewers {
+		_, err = tx.Exec(`
+			INSERT INTO pr_reviewers (pr_id, reviewer_id, assigned_at, role)
+			VALUES ($1, $2, $3, 'SHADOW')
+		`, pr.ID, shadowID, time.Now())
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			UPDATE team_shadow_reviewers
+			SET last_assigned_at = $1
+			WHERE team_id = $2 AND user_id = $3
+		`, time.Now(), shadowTeamID, shadowID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, userID := range excluded {
+		_, err = tx.Exec(`
+			INSERT INTO pr_excluded_reviewers (pr_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, pr.ID, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, teamID := range pr.ReviewTeamIDs {
+		_, err = tx.Exec(`
+			INSERT INTO pr_review_teams (pr_id, team_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, pr.ID, teamID)
+		if err != nil {
+			return err
+		}
+	}
+	if len(pr.TargetTeamIDs) > 0 {
+		_, err = tx.Exec(`
+			UPDATE pr_review_teams
+			SET approval_required = true
+			WHERE pr_id = $1 AND team_id = ANY($2::uuid[])
+		`, pr.ID, pq.StringArray(uuidStrings(pr.TargetTeamIDs)))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, depID := range pr.DependsOn {
+		_, err = tx.Exec(`
+			INSERT INTO pr_dependencies (pr_id, depends_on_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, pr.ID, depID)
+		if err != nil {
+			return err
+		}
+	}
+
 	return tx.Commit()
 }
 
 // GetByID возвращает PR по ID с ревьюверами
 func (r *PRRepository) GetByID(id uuid.UUID) (*model.PullRequest, error) {
 	query := `
-		SELECT id, pull_request_name, author_id, status, created_at, merged_at
+		SELECT id, external_id, pull_request_name, author_id, repository_id, required_reviewers, lines_added, lines_removed, files_changed, high_risk, priority, review_due_at, labels, version, status, created_at, merged_at
 		FROM pull_requests
 		WHERE id = $1
 	`
 	row := r.DB.QueryRow(query, id)
 	var pr model.PullRequest
-	err := row.Scan(&pr.ID, &pr.Title, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &pr.MergedAt)
+	err := row.Scan(&pr.ID, &pr.ExternalID, &pr.Title, &pr.AuthorID, &pr.RepositoryID, &pr.RequiredReviewers, &pr.DiffStats.LinesAdded, &pr.DiffStats.LinesRemoved, &pr.DiffStats.FilesChanged, &pr.DiffStats.HighRisk, &pr.Priority, &pr.ReviewDueAt, (*pq.StringArray)(&pr.Labels), &pr.Version, &pr.Status, &pr.CreatedAt, &pr.MergedAt)
 	if err != nil {
 		return nil, err
 	}
 
-	reviewersQuery := `
-		SELECT reviewer_id
-		FROM pr_reviewers
-		WHERE pr_id = $1
-		ORDER BY assigned_at
-	`
-	reviewerRows, errQuery := r.DB.Query(reviewersQuery, id)
-	if errQuery != nil {
-		return nil, errQuery
-	}
-	defer func() {
-		err = reviewerRows.Close()
-		if err != nil {
-			return
-		}
-	}()
-	if err := reviewerRows.Err(); err != nil {
+	prs := []model.PullRequest{pr}
+	if err = r.loadRelations(prs); err != nil {
 		return nil, err
 	}
-	var reviewers []uuid.UUID
-	for reviewerRows.Next() {
-		var reviewerID uuid.UUID
-		if err := reviewerRows.Scan(&reviewerID); err != nil {
-			return nil, err
-		}
-		reviewers = append(reviewers, reviewerID)
-	}
-	pr.Reviewers = reviewers
 
-	return &pr, nil
+	return &prs[0], nil
+}
+
+// GetByExternalID возвращает PR по внешнему идентификатору
+func (r *PRRepository) GetByExternalID(externalID string) (*model.PullRequest, error) {
+	var id uuid.UUID
+	err := r.DB.QueryRow(`SELECT id FROM pull_requests WHERE external_id = $1`, externalID).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
 }
 
 // Update обновляет PR
@@ -109,8 +181,16 @@ func (r *PRRepository) Update(pr *model.PullRequest) error {
 	return err
 }
 
-// ReassignReviewer заменяет одного ревьювера на другого в указанном PR.
-func (r *PRRepository) ReassignReviewer(prID, oldReviewerID, newReviewerID uuid.UUID) error {
+// ReassignReviewer заменяет одного ревьювера на другого в указанном PR и
+// записывает замену в историю. Если newReviewerID равен uuid.Nil, ревьювер
+// только снимается с PR. Если версия PR не равна expectedVersion (AnyVersion — без проверки),
+// возвращает ErrStaleVersion; иначе версия PR увеличивается.
+func (r *PRRepository) ReassignReviewer(
+	prID, oldReviewerID, newReviewerID uuid.UUID,
+	kind model.ReviewerChangeKind,
+	reason string,
+	expectedVersion int64,
+) error {
 	tx, err := r.DB.Begin()
 	if err != nil {
 		return err
@@ -123,19 +203,24 @@ func (r *PRRepository) ReassignReviewer(prID, oldReviewerID, newReviewerID uuid.
 	}()
 
 	var status string
+	var version int64
 	var reviewerExists bool
 	checkQuery := `
-		SELECT pr.status, EXISTS(
-			SELECT 1 FROM pr_reviewers 
-			WHERE pr_id = $1 AND reviewer_id = $2
+		SELECT pr.status, pr.version, EXISTS(
+			SELECT 1 FROM pr_reviewers
+			WHERE pr_id = $1 AND reviewer_id = $2 AND role = 'REQUIRED'
 		)
 		FROM pull_requests pr
 		WHERE pr.id = $1
+		FOR UPDATE OF pr
 	`
-	err = tx.QueryRow(checkQuery, prID, oldReviewerID).Scan(&status, &reviewerExists)
+	err = tx.QueryRow(checkQuery, prID, oldReviewerID).Scan(&status, &version, &reviewerExists)
 	if err != nil {
 		return err
 	}
+	if expectedVersion != AnyVersion && version != expectedVersion {
+		return ErrStaleVersion
+	}
 	if status == "MERGED" {
 		return sql.ErrNoRows
 	}
@@ -152,20 +237,59 @@ func (r *PRRepository) ReassignReviewer(prID, oldReviewerID, newReviewerID uuid.
 		return err
 	}
 
-	insertQuery := `
-		INSERT INTO pr_reviewers (pr_id, reviewer_id, assigned_at)
-		VALUES ($1, $2, $3)
+	var newReviewer *uuid.UUID
+	if newReviewerID != uuid.Nil {
+		insertQuery := `
+			INSERT INTO pr_reviewers (pr_id, reviewer_id, assigned_at)
+			VALUES ($1, $2, $3)
+		`
+		_, err = tx.Exec(insertQuery, prID, newReviewerID, time.Now())
+		if err != nil {
+			return err
+		}
+		newReviewer = &newReviewerID
+	}
+
+	historyQuery := `
+		INSERT INTO pr_reviewer_changes (pr_id, old_reviewer_id, new_reviewer_id, kind, reason)
+		VALUES ($1, $2, $3, $4, $5)
 	`
-	_, err = tx.Exec(insertQuery, prID, newReviewerID, time.Now())
+	_, err = tx.Exec(historyQuery, prID, oldReviewerID, newReviewer, kind, reason)
 	if err != nil {
 		return err
 	}
 
+	if err = bumpPRVersion(tx, prID); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
-// Merge переводит PR в статус MERGED (идемпотентно)
-func (r *PRRepository) Merge(prID uuid.UUID) error {
+// GetExcludedReviewers возвращает пользователей, которых нельзя назначать ревьюверами PR:
+// отказавшихся от ревью и исключенных автором при создании.
+func (r *PRRepository) GetExcludedReviewers(prID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT old_reviewer_id
+		FROM pr_reviewer_changes
+		WHERE pr_id = $1 AND kind = 'DECLINE'
+		UNION
+		SELECT user_id
+		FROM pr_excluded_reviewers
+		WHERE pr_id = $1
+	`
+	return r.queryIDs(query, prID)
+}
+
+// Merge переводит PR в статус MERGED (идемпотентно). Версия проверяется только у открытого PR:
+// если она не равна expectedVersion (AnyVersion — без проверки), возвращает ErrStaleVersion.
+// Если у PR остались несмерженные зависимости, возвращает *DependenciesBlockedError:
+// проверка выполняется в той же транзакции под блокировкой графа зависимостей, поэтому
+// параллельно добавленная зависимость не проскочит мимо нее. Если от целевой команды еще нет
+// одобрения обязательного ревьювера, возвращает *ApprovalsMissingError: одобрения и ревизии
+// меняются под блокировкой строки PR, поэтому проверка после нее видит окончательное состояние.
+// События PR_UNBLOCKED зависимых PR записываются в той же транзакции.
+func (r *PRRepository) Merge(prID uuid.UUID, expectedVersion int64) error {
 	tx, err := r.DB.Begin()
 	if err != nil {
 		return err
@@ -177,10 +301,17 @@ func (r *PRRepository) Merge(prID uuid.UUID) error {
 		}
 	}()
 
+	// Блокировка графа берется до блокировки строки в том же порядке, что и в AddDependencies:
+	// вставка зависимости ждет строку PR (внешний ключ), и обратный порядок привел бы к взаимоблокировке
+	_, err = tx.Exec(`SELECT pg_advisory_xact_lock($1)`, dependencyGraphLock)
+	if err != nil {
+		return err
+	}
+
 	var currentStatus string
-	var mergedAt *time.Time
-	statusQuery := `SELECT status, merged_at FROM pull_requests WHERE id = $1`
-	err = tx.QueryRow(statusQuery, prID).Scan(&currentStatus, &mergedAt)
+	var version int64
+	statusQuery := `SELECT status, version FROM pull_requests WHERE id = $1 FOR UPDATE`
+	err = tx.QueryRow(statusQuery, prID).Scan(&currentStatus, &version)
 	if err != nil {
 		return err
 	}
@@ -188,6 +319,25 @@ func (r *PRRepository) Merge(prID uuid.UUID) error {
 	if currentStatus == "MERGED" {
 		return tx.Commit()
 	}
+	if expectedVersion != AnyVersion && version != expectedVersion {
+		return ErrStaleVersion
+	}
+
+	blocking, err := blockingDependencies(tx, prID)
+	if err != nil {
+		return err
+	}
+	if len(blocking) > 0 {
+		return &DependenciesBlockedError{PRIDs: blocking}
+	}
+
+	missing, err := teamsMissingApproval(tx, prID)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return &ApprovalsMissingError{TeamIDs: missing}
+	}
 
 	now := time.Now()
 	updateQuery := `
@@ -200,64 +350,94 @@ func (r *PRRepository) Merge(prID uuid.UUID) error {
 		return err
 	}
 
+	if err = notifyUnblockedDependents(tx, prID); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
-// GetAll возвращает все PR
-func (r *PRRepository) GetAll() ([]model.PullRequest, error) {
-	query := `
-		SELECT id, pull_request_name, author_id, status, created_at, merged_at
-		FROM pull_requests
-		ORDER BY created_at DESC
-	`
-	rows, err := r.DB.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		err = rows.Close()
+// replaceReviewers применяет замены ревьюверов в рамках транзакции. Если PR уже не открыт
+// или ревьювер с него снят, возвращает sql.ErrNoRows.
+func replaceReviewers(tx *sql.Tx, replacements []model.ReviewerReplacement, kind model.ReviewerChangeKind, reason string) error {
+	for _, rep := range replacements {
+		var status string
+		err := tx.QueryRow(`SELECT status FROM pull_requests WHERE id = $1 FOR UPDATE`, rep.PRID).Scan(&status)
 		if err != nil {
-			return
+			return err
 		}
-	}()
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	var prs []model.PullRequest
-	for rows.Next() {
-		var pr model.PullRequest
-		err = rows.Scan(&pr.ID, &pr.Title, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &pr.MergedAt)
-		if err != nil {
-			return nil, err
+		if status != "OPEN" {
+			return sql.ErrNoRows
 		}
 
-		reviewersQuery := `SELECT reviewer_id FROM pr_reviewers WHERE pr_id = $1 ORDER BY assigned_at`
-		reviewerRows, errQuery := r.DB.Query(reviewersQuery, pr.ID)
-		if errQuery != nil {
-			return nil, errQuery
+		result, err := tx.Exec(`
+			DELETE FROM pr_reviewers
+			WHERE pr_id = $1 AND reviewer_id = $2
+		`, rep.PRID, rep.OldReviewerID)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return sql.ErrNoRows
 		}
-		var reviewers []uuid.UUID
-		for reviewerRows.Next() {
-			var reviewerID uuid.UUID
-			if err = reviewerRows.Scan(&reviewerID); err != nil {
-				err = reviewerRows.Close()
-				if err != nil {
-					return nil, err
-				}
-				return nil, err
+
+		if rep.NewReviewerID != nil {
+			_, err = tx.Exec(`
+				INSERT INTO pr_reviewers (pr_id, reviewer_id, assigned_at)
+				VALUES ($1, $2, $3)
+			`, rep.PRID, *rep.NewReviewerID, time.Now())
+			if err != nil {
+				return err
 			}
-			reviewers = append(reviewers, reviewerID)
 		}
-		err = reviewerRows.Close()
+
+		_, err = tx.Exec(`
+			INSERT INTO pr_reviewer_changes (pr_id, old_reviewer_id, new_reviewer_id, kind, reason)
+			VALUES ($1, $2, $3, $4, $5)
+		`, rep.PRID, rep.OldReviewerID, rep.NewReviewerID, kind, reason)
+		if err != nil {
+			return err
+		}
+
+		if err = bumpPRVersion(tx, rep.PRID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddOptionalReviewers добавляет опциональных ревьюверов (наблюдателей) к PR.
+// Уже назначенные пользователи (в любой роли) пропускаются.
+func (r *PRRepository) AddOptionalReviewers(prID uuid.UUID, userIDs []uuid.UUID) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = tx.Rollback()
 		if err != nil {
-			return nil, err
+			return
 		}
-		pr.Reviewers = reviewers
-		if err := reviewerRows.Err(); err != nil {
-			return nil, err
+	}()
+
+	for _, userID := range userIDs {
+		_, err = tx.Exec(`
+			INSERT INTO pr_reviewers (pr_id, reviewer_id, assigned_at, role)
+			VALUES ($1, $2, $3, 'OPTIONAL')
+			ON CONFLICT DO NOTHING
+		`, prID, userID, time.Now())
+		if err != nil {
+			return err
 		}
-		prs = append(prs, pr)
 	}
 
-	return prs, nil
+	if err = bumpPRVersion(tx, prID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
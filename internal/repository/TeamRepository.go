@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type TeamRepository struct {
@@ -21,21 +22,21 @@ func (r *TeamRepository) Create(team *model.Team) error {
 	query := `
 		INSERT INTO teams (id, name, created_at)
 		VALUES ($1, $2, $3)
+		RETURNING version
 	`
-	_, err := r.DB.Exec(query, team.ID, team.Name, time.Now())
-	return err
+	return r.DB.QueryRow(query, team.ID, team.Name, time.Now()).Scan(&team.Version)
 }
 
 // GetByID возвращает команду по ID
 func (r *TeamRepository) GetByID(id uuid.UUID) (*model.Team, error) {
 	query := `
-		SELECT id, name
+		SELECT id, name, shadowing_enabled, reset_approvals_on_revision, version
 		FROM teams
 		WHERE id = $1
 	`
 	row := r.DB.QueryRow(query, id)
 	var team model.Team
-	err := row.Scan(&team.ID, &team.Name)
+	err := row.Scan(&team.ID, &team.Name, &team.ShadowingEnabled, &team.ResetApprovalsOnRevision, &team.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -45,13 +46,13 @@ func (r *TeamRepository) GetByID(id uuid.UUID) (*model.Team, error) {
 // GetByName возвращает команду по имени
 func (r *TeamRepository) GetByName(name string) (*model.Team, error) {
 	query := `
-		SELECT id, name
+		SELECT id, name, shadowing_enabled, reset_approvals_on_revision, version
 		FROM teams
 		WHERE name = $1
 	`
 	row := r.DB.QueryRow(query, name)
 	var team model.Team
-	err := row.Scan(&team.ID, &team.Name)
+	err := row.Scan(&team.ID, &team.Name, &team.ShadowingEnabled, &team.ResetApprovalsOnRevision, &team.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +62,7 @@ func (r *TeamRepository) GetByName(name string) (*model.Team, error) {
 // GetMembers возвращает всех участников команды
 func (r *TeamRepository) GetMembers(teamID uuid.UUID) ([]model.User, error) {
 	query := `
-		SELECT id, username, team_id, is_active
+		SELECT id, username, team_id, is_active, is_senior, version
 		FROM users
 		WHERE team_id = $1
 		ORDER BY username
@@ -75,7 +76,7 @@ func (r *TeamRepository) GetMembers(teamID uuid.UUID) ([]model.User, error) {
 	var members []model.User
 	for rows.Next() {
 		var u model.User
-		err := rows.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive)
+		err := rows.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive, &u.IsSenior, &u.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -87,19 +88,180 @@ func (r *TeamRepository) GetMembers(teamID uuid.UUID) ([]model.User, error) {
 	return members, nil
 }
 
-// Update обновляет команду
-func (r *TeamRepository) Update(team *model.Team) error {
+// Update обновляет название и настройки команды, если ее версия равна expectedVersion (AnyVersion — без проверки),
+// и записывает в team.Version новую версию. Если команда есть, но версия другая, возвращает ErrStaleVersion.
+func (r *TeamRepository) Update(team *model.Team, expectedVersion int64) error {
 	query := `
 		UPDATE teams
-		SET name = $1
-		WHERE id = $2
+		SET name = $1, shadowing_enabled = $2, reset_approvals_on_revision = $3
+		WHERE id = $4 AND ($5 = 0 OR version = $5)
+		RETURNING version
 	`
-	_, err := r.DB.Exec(query, team.Name, team.ID)
+	err := r.DB.QueryRow(query, team.Name, team.ShadowingEnabled, team.ResetApprovalsOnRevision,
+		team.ID, expectedVersion).Scan(&team.Version)
+	if err == sql.ErrNoRows && expectedVersion != AnyVersion {
+		if _, errGet := r.GetByID(team.ID); errGet == nil {
+			return ErrStaleVersion
+		}
+	}
 	return err
 }
 
-// Delete удаляет команду
-func (r *TeamRepository) Delete(id uuid.UUID) error {
-	_, err := r.DB.Exec("DELETE FROM teams WHERE id = $1", id)
-	return err
+// Delete удаляет команду. Возвращает false, если команды не было.
+func (r *TeamRepository) Delete(id uuid.UUID) (bool, error) {
+	result, err := r.DB.Exec("DELETE FROM teams WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// GetShadowUsers возвращает джуниоров команды, назначаемых теневыми ревьюверами
+func (r *TeamRepository) GetShadowUsers(teamID uuid.UUID) ([]uuid.UUID, error) {
+	return queryIDs(r.DB, `
+		SELECT user_id
+		FROM team_shadow_reviewers
+		WHERE team_id = $1
+		ORDER BY user_id
+	`, teamID)
+}
+
+// NextShadowReviewer возвращает следующего по ротации активного джуниора команды
+// (дольше всех не назначавшегося), исключая указанных пользователей.
+// Если подходящих нет, возвращает sql.ErrNoRows.
+func (r *TeamRepository) NextShadowReviewer(teamID uuid.UUID, excludeIDs []uuid.UUID) (uuid.UUID, error) {
+	query := `
+		SELECT s.user_id
+		FROM team_shadow_reviewers s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.team_id = $1 AND u.is_active = true AND NOT (s.user_id = ANY($2::uuid[]))
+		ORDER BY s.last_assigned_at NULLS FIRST, u.username
+		LIMIT 1
+	`
+	var id uuid.UUID
+	err := r.DB.QueryRow(query, teamID, pq.StringArray(uuidStrings(excludeIDs))).Scan(&id)
+	return id, err
+}
+
+// UpdateShadowing включает/выключает теневых ревьюверов и заменяет список джуниоров команды.
+// Если версия команды не равна expectedVersion (AnyVersion — без проверки), возвращает ErrStaleVersion.
+func (r *TeamRepository) UpdateShadowing(teamID uuid.UUID, enabled bool, userIDs []uuid.UUID, expectedVersion int64) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	err = execVersioned(tx, `
+		UPDATE teams
+		SET shadowing_enabled = $1
+		WHERE id = $2 AND ($3 = 0 OR version = $3)
+	`, enabled, teamID, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	// Удаляем только выбывших, чтобы не сбрасывать ротацию оставшихся
+	_, err = tx.Exec(`
+		DELETE FROM team_shadow_reviewers
+		WHERE team_id = $1 AND NOT (user_id = ANY($2::uuid[]))
+	`, teamID, pq.StringArray(uuidStrings(userIDs)))
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		_, err = tx.Exec(`
+			INSERT INTO team_shadow_reviewers (team_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, teamID, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetReviewerTiers возвращает уровни числа ревьюверов команды по возрастанию размера PR
+func (r *TeamRepository) GetReviewerTiers(teamID uuid.UUID) ([]model.ReviewerTier, error) {
+	rows, err := r.DB.Query(`
+		SELECT min_lines, high_risk, reviewers, senior_reviewers
+		FROM team_reviewer_tiers
+		WHERE team_id = $1
+		ORDER BY high_risk, min_lines
+	`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tiers := make([]model.ReviewerTier, 0)
+	for rows.Next() {
+		var t model.ReviewerTier
+		if err := rows.Scan(&t.MinLines, &t.HighRisk, &t.Reviewers, &t.SeniorReviewers); err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tiers, nil
+}
+
+// ReplaceReviewerTiers полностью заменяет уровни числа ревьюверов команды.
+// Если версия команды не равна expectedVersion (AnyVersion — без проверки), возвращает ErrStaleVersion.
+func (r *TeamRepository) ReplaceReviewerTiers(teamID uuid.UUID, tiers []model.ReviewerTier, expectedVersion int64) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	if err = bumpTeamVersion(tx, teamID, expectedVersion); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM team_reviewer_tiers WHERE team_id = $1`, teamID)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tiers {
+		_, err = tx.Exec(`
+			INSERT INTO team_reviewer_tiers (team_id, min_lines, high_risk, reviewers, senior_reviewers)
+			VALUES ($1, $2, $3, $4, $5)
+		`, teamID, t.MinLines, t.HighRisk, t.Reviewers, t.SeniorReviewers)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateReviewPolicy задает, сбрасываются ли одобрения PR команды при новой ревизии.
+// Если версия команды не равна expectedVersion (AnyVersion — без проверки), возвращает ErrStaleVersion.
+func (r *TeamRepository) UpdateReviewPolicy(teamID uuid.UUID, resetApprovals bool, expectedVersion int64) error {
+	return execVersioned(r.DB, `
+		UPDATE teams
+		SET reset_approvals_on_revision = $1
+		WHERE id = $2 AND ($3 = 0 OR version = $3)
+	`, resetApprovals, teamID, expectedVersion)
 }
@@ -4,11 +4,12 @@ package repository
 import (
 	"avito-assignment/internal/model"
 	"database/sql"
+	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // UserRepository предоставляет методы для работы с пользователями в базе данных.
@@ -24,57 +25,114 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 // CreateUser сохраняет нового пользователя в базе данных.
 func (r *UserRepository) CreateUser(user *model.User) error {
 	query := `
-		INSERT INTO users (id, username, team_id, is_active, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (id, username, team_id, is_active, is_senior, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING version
 	`
-	_, err := r.DB.Exec(query, user.ID, user.Username, user.TeamID, user.IsActive, time.Now())
-	return err
+	return r.DB.QueryRow(query, user.ID, user.Username, user.TeamID, user.IsActive, user.IsSenior, time.Now()).Scan(&user.Version)
 }
 
 // GetUserByID возвращает пользователя по его уникальному идентификатору.
 func (r *UserRepository) GetUserByID(id uuid.UUID) (*model.User, error) {
 	query := `
-		SELECT id, username, team_id, is_active
+		SELECT id, username, team_id, is_active, is_senior, version
 		FROM users
 		WHERE id = $1
 	`
 	row := r.DB.QueryRow(query, id)
 	var u model.User
-	err := row.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive)
+	err := row.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive, &u.IsSenior, &u.Version)
 	if err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
-// Update обновляет пользователя.
-func (r *UserRepository) Update(user *model.User) (*model.User, error) {
+// GetUserByUsername возвращает пользователя по имени.
+func (r *UserRepository) GetUserByUsername(username string) (*model.User, error) {
+	query := `
+		SELECT id, username, team_id, is_active, is_senior, version
+		FROM users
+		WHERE username = $1
+	`
+	row := r.DB.QueryRow(query, username)
+	var u model.User
+	err := row.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive, &u.IsSenior, &u.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// Update обновляет пользователя, если его версия равна expectedVersion (AnyVersion — без проверки).
+// Если пользователь есть, но версия другая, возвращает ErrStaleVersion. Замены ревьюверов
+// offboarding (снятие пользователя с открытых ревью) применяются в той же транзакции;
+// если ревьюверов PR успели изменить, тоже возвращается ErrStaleVersion.
+func (r *UserRepository) Update(user *model.User, expectedVersion int64, offboarding []model.ReviewerReplacement) (*model.User, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	var version int64
+	err = tx.QueryRow(`SELECT version FROM users WHERE id = $1 FOR UPDATE`, user.ID).Scan(&version)
+	if err != nil {
+		return nil, err
+	}
+	if expectedVersion != AnyVersion && version != expectedVersion {
+		return nil, ErrStaleVersion
+	}
+
+	err = replaceReviewers(tx, offboarding, model.ReviewerOffboarded, "offboarding")
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrStaleVersion
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		UPDATE users
-		SET username=$1, team_id=$2, is_active=$3
-		WHERE id=$4
-		RETURNING id, username, team_id, is_active
+		SET username=$1, team_id=$2, is_active=$3, is_senior=$4
+		WHERE id=$5
+		RETURNING id, username, team_id, is_active, is_senior, version
 	`
-	row := r.DB.QueryRow(query, user.Username, user.TeamID, user.IsActive, user.ID)
+	row := tx.QueryRow(query, user.Username, user.TeamID, user.IsActive, user.IsSenior, user.ID)
 	var u model.User
-	err := row.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive)
+	err = row.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive, &u.IsSenior, &u.Version)
 	if err != nil {
 		return nil, err
 	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
 	return &u, nil
 }
 
-// Delete удаляет пользователя
-func (r *UserRepository) Delete(id uuid.UUID) error {
-	_, err := r.DB.Exec("DELETE FROM users WHERE id=$1", id)
-	return err
+// Delete удаляет пользователя и сообщает, существовал ли он
+func (r *UserRepository) Delete(id uuid.UUID) (bool, error) {
+	result, err := r.DB.Exec("DELETE FROM users WHERE id=$1", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
 }
 
 // GetPRsByReviewer возвращает список всех Pull Request, где указанный пользователь
 // назначен ревьювером.
 func (r *UserRepository) GetPRsByReviewer(userID uuid.UUID) ([]model.PullRequest, error) {
 	query := `
-		SELECT pr.id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at, pr.merged_at
+		SELECT pr.id, pr.external_id, pr.pull_request_name, pr.author_id, pr.repository_id, pr.required_reviewers, pr.lines_added, pr.lines_removed, pr.files_changed, pr.high_risk, pr.priority, pr.review_due_at, pr.labels, pr.version, pr.status, pr.created_at, pr.merged_at
 		FROM pull_requests pr
 		JOIN pr_reviewers rr ON rr.pr_id = pr.id
 		WHERE rr.reviewer_id = $1
@@ -94,12 +152,12 @@ func (r *UserRepository) GetPRsByReviewer(userID uuid.UUID) ([]model.PullRequest
 	var prs []model.PullRequest
 	for rows.Next() {
 		var pr model.PullRequest
-		err := rows.Scan(&pr.ID, &pr.Title, &pr.AuthorID, &pr.Status, &pr.CreatedAt, &pr.MergedAt)
+		err := rows.Scan(&pr.ID, &pr.ExternalID, &pr.Title, &pr.AuthorID, &pr.RepositoryID, &pr.RequiredReviewers, &pr.DiffStats.LinesAdded, &pr.DiffStats.LinesRemoved, &pr.DiffStats.FilesChanged, &pr.DiffStats.HighRisk, &pr.Priority, &pr.ReviewDueAt, (*pq.StringArray)(&pr.Labels), &pr.Version, &pr.Status, &pr.CreatedAt, &pr.MergedAt)
 		if err != nil {
 			return nil, err
 		}
 
-		reviewersQuery := `SELECT reviewer_id FROM pr_reviewers WHERE pr_id = $1`
+		reviewersQuery := `SELECT reviewer_id FROM pr_reviewers WHERE pr_id = $1 AND role = 'REQUIRED'`
 		reviewerRows, err := r.DB.Query(reviewersQuery, pr.ID)
 		if err != nil {
 			return nil, err
@@ -122,6 +180,14 @@ func (r *UserRepository) GetPRsByReviewer(userID uuid.UUID) ([]model.PullRequest
 			return nil, err
 		}
 		pr.Reviewers = reviewers
+		pr.OptionalReviewers, err = queryIDs(r.DB, reviewersByRoleQuery, pr.ID, model.RoleOptional)
+		if err != nil {
+			return nil, err
+		}
+		pr.ShadowReviewers, err = queryIDs(r.DB, reviewersByRoleQuery, pr.ID, model.RoleShadow)
+		if err != nil {
+			return nil, err
+		}
 		prs = append(prs, pr)
 		if err := reviewerRows.Err(); err != nil {
 			return nil, err
@@ -136,7 +202,7 @@ func (r *UserRepository) GetPRsByReviewer(userID uuid.UUID) ([]model.PullRequest
 // GetActiveUsersByTeam возвращает список активных пользователей команды,
 func (r *UserRepository) GetActiveUsersByTeam(teamID, excludeID uuid.UUID) ([]model.User, error) {
 	query := `
-		SELECT id, username, team_id, is_active
+		SELECT id, username, team_id, is_active, is_senior, version
 		FROM users
 		WHERE team_id = $1 AND is_active = true AND id != $2
 		ORDER BY username
@@ -150,7 +216,7 @@ func (r *UserRepository) GetActiveUsersByTeam(teamID, excludeID uuid.UUID) ([]mo
 	var users []model.User
 	for rows.Next() {
 		var u model.User
-		err := rows.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive)
+		err := rows.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive, &u.IsSenior, &u.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -162,14 +228,48 @@ func (r *UserRepository) GetActiveUsersByTeam(teamID, excludeID uuid.UUID) ([]mo
 	return users, nil
 }
 
-// DeactivateTeamMembers массово деактивирует всех пользователей команды
-func (r *UserRepository) DeactivateTeamMembers(teamID uuid.UUID) (int, error) {
+// DeactivateTeamMembers массово деактивирует активных участников команды members и в той же
+// транзакции применяет замены ревьюверов offboarding. Если активные участники команды или
+// ревьюверы PR изменились после чтения, возвращает ErrStaleVersion.
+func (r *UserRepository) DeactivateTeamMembers(teamID uuid.UUID, members []model.User, offboarding []model.ReviewerReplacement) (int, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	current, err := lockActiveTeamMembers(tx, teamID)
+	if err != nil {
+		return 0, err
+	}
+	if len(current) != len(members) {
+		return 0, ErrStaleVersion
+	}
+	for _, m := range members {
+		if version, ok := current[m.ID]; !ok || version != m.Version {
+			return 0, ErrStaleVersion
+		}
+	}
+
+	err = replaceReviewers(tx, offboarding, model.ReviewerOffboarded, "offboarding")
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrStaleVersion
+	}
+	if err != nil {
+		return 0, err
+	}
+
 	query := `
 		UPDATE users
 		SET is_active = false
 		WHERE team_id = $1 AND is_active = true
 	`
-	result, err := r.DB.Exec(query, teamID)
+	result, err := tx.Exec(query, teamID)
 	if err != nil {
 		return 0, err
 	}
@@ -179,11 +279,32 @@ func (r *UserRepository) DeactivateTeamMembers(teamID uuid.UUID) (int, error) {
 		return 0, err
 	}
 
-	return int(rowsAffected), nil
+	return int(rowsAffected), tx.Commit()
+}
+
+// lockActiveTeamMembers блокирует активных участников команды и возвращает их версии.
+func lockActiveTeamMembers(tx *sql.Tx, teamID uuid.UUID) (map[uuid.UUID]int64, error) {
+	rows, err := tx.Query(`SELECT id, version FROM users WHERE team_id = $1 AND is_active = true FOR UPDATE`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[uuid.UUID]int64)
+	for rows.Next() {
+		var id uuid.UUID
+		var version int64
+		if err := rows.Scan(&id, &version); err != nil {
+			return nil, err
+		}
+		versions[id] = version
+	}
+	return versions, rows.Err()
 }
+
 func (r *UserRepository) GetUsersByTeam(teamID uuid.UUID) ([]model.User, error) {
 	query := `
-			SELECT id, username, team_id, is_active
+			SELECT id, username, team_id, is_active, is_senior, version
 			FROM users
 			WHERE team_id = $1 AND is_active = true
 			ORDER BY username
@@ -197,7 +318,7 @@ func (r *UserRepository) GetUsersByTeam(teamID uuid.UUID) ([]model.User, error)
 	var users []model.User
 	for rows.Next() {
 		var u model.User
-		err := rows.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive)
+		err := rows.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive, &u.IsSenior, &u.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -210,62 +331,31 @@ func (r *UserRepository) GetUsersByTeam(teamID uuid.UUID) ([]model.User, error)
 }
 
 // GetActiveUsersByTeamExcluding возвращает активных пользователей команды, исключая несколько пользователей
-func (r *UserRepository) GetActiveUsersByTeamExcluding(teamID uuid.UUID, excludeIDs []uuid.UUID) ([]model.User, error) {
-	if len(excludeIDs) == 0 {
-		query := `
-			SELECT id, username, team_id, is_active
-			FROM users
-			WHERE team_id = $1 AND is_active = true
-			ORDER BY username
-		`
-		rows, err := r.DB.Query(query, teamID)
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
+// и тех, кому правила конфликта интересов запрещают ревьюить автора authorID
+func (r *UserRepository) GetActiveUsersByTeamExcluding(teamID, authorID uuid.UUID, excludeIDs []uuid.UUID) ([]model.User, error) {
+	return r.GetActiveUsersByTeams([]uuid.UUID{teamID}, authorID, excludeIDs)
+}
 
-		var users []model.User
-		for rows.Next() {
-			var u model.User
-			err := rows.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive)
-			if err != nil {
-				return nil, err
-			}
-			users = append(users, u)
-		}
-		return users, nil
-	}
+// GetActiveUsersByTeams возвращает активных пользователей нескольких команд, исключая указанных
+// пользователей и тех, кому правила конфликта интересов запрещают ревьюить автора authorID
+func (r *UserRepository) GetActiveUsersByTeams(teamIDs []uuid.UUID, authorID uuid.UUID, excludeIDs []uuid.UUID) ([]model.User, error) {
 	query := `
-		SELECT id, username, team_id, is_active
-		FROM users
-		WHERE team_id = $1 AND is_active = true
+		SELECT u.id, u.username, u.team_id, u.is_active, u.is_senior, u.version
+		FROM users u
+		WHERE u.team_id = ANY($1::uuid[]) AND u.is_active = true AND NOT (u.id = ANY($2::uuid[]))
+		  AND ` + fmt.Sprintf(conflictFilter, 3) + `
+		ORDER BY u.username
 	`
-	args := []interface{}{teamID}
-	excludeConditions := make([]string, len(excludeIDs))
-	for i, excludeID := range excludeIDs {
-		excludeConditions[i] = fmt.Sprintf("$%d", i+2)
-		args = append(args, excludeID)
-	}
-	if len(excludeConditions) > 0 {
-		query += " AND id NOT IN (" + strings.Join(excludeConditions, ", ") + ")"
-	}
-	query += ` ORDER BY username`
-
-	rows, err := r.DB.Query(query, args...)
+	rows, err := r.DB.Query(query, pq.StringArray(uuidStrings(teamIDs)), pq.StringArray(uuidStrings(excludeIDs)), authorID)
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		err = rows.Close()
-		if err != nil {
-			panic(err)
-		}
-	}()
+	defer rows.Close()
 
 	var users []model.User
 	for rows.Next() {
 		var u model.User
-		err := rows.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive)
+		err := rows.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive, &u.IsSenior, &u.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -276,3 +366,42 @@ func (r *UserRepository) GetActiveUsersByTeamExcluding(teamID uuid.UUID, exclude
 	}
 	return users, nil
 }
+
+// GetOpenReviewCounts возвращает количество открытых PR, где пользователь — обязательный ревьювер
+func (r *UserRepository) GetOpenReviewCounts(userIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	query := `
+		SELECT rr.reviewer_id, COUNT(*)
+		FROM pr_reviewers rr
+		JOIN pull_requests pr ON pr.id = rr.pr_id
+		WHERE rr.reviewer_id = ANY($1::uuid[]) AND rr.role = 'REQUIRED' AND pr.status = 'OPEN'
+		GROUP BY rr.reviewer_id
+	`
+	rows, err := r.DB.Query(query, pq.StringArray(uuidStrings(userIDs)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[uuid.UUID]int, len(userIDs))
+	for rows.Next() {
+		var id uuid.UUID
+		var count int
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		counts[id] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// uuidStrings преобразует список UUID в строки для передачи массивом в PostgreSQL.
+func uuidStrings(ids []uuid.UUID) []string {
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, id.String())
+	}
+	return result
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"avito-assignment/internal/model"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// conflictFilter исключает кандидата u, если правило конфликта интересов запрещает
+// ему ревьюить автора (параметр с номером authorParam).
+const conflictFilter = `
+	NOT EXISTS (
+		SELECT 1 FROM reviewer_conflicts c
+		WHERE (c.reviewer_id = u.id AND c.author_id = $%[1]d)
+		   OR (c.mutual AND c.reviewer_id = $%[1]d AND c.author_id = u.id)
+	)
+`
+
+// ConflictRepository предоставляет методы для работы с правилами конфликта интересов.
+type ConflictRepository struct {
+	DB *sql.DB
+}
+
+// NewConflictRepository создает новый экземпляр ConflictRepository.
+func NewConflictRepository(db *sql.DB) *ConflictRepository {
+	return &ConflictRepository{DB: db}
+}
+
+// Create сохраняет правило
+func (r *ConflictRepository) Create(rule *model.ConflictRule) error {
+	rule.CreatedAt = time.Now()
+	query := `
+		INSERT INTO reviewer_conflicts (id, reviewer_id, author_id, mutual, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.DB.Exec(query, rule.ID, rule.ReviewerID, rule.AuthorID, rule.Mutual, rule.Reason, rule.CreatedAt)
+	return err
+}
+
+// GetAll возвращает все правила
+func (r *ConflictRepository) GetAll() ([]model.ConflictRule, error) {
+	return r.query(`
+		SELECT id, reviewer_id, author_id, mutual, reason, created_at
+		FROM reviewer_conflicts
+		ORDER BY created_at
+	`)
+}
+
+// GetForAuthor возвращает правила, запрещающие кому-либо ревьюить автора.
+// Для взаимных правил ReviewerID/AuthorID приводятся к направлению «кто → автор».
+func (r *ConflictRepository) GetForAuthor(authorID uuid.UUID) ([]model.ConflictRule, error) {
+	return r.query(`
+		SELECT id,
+		       CASE WHEN author_id = $1 THEN reviewer_id ELSE author_id END,
+		       $1,
+		       mutual, reason, created_at
+		FROM reviewer_conflicts
+		WHERE author_id = $1 OR (mutual AND reviewer_id = $1)
+		ORDER BY created_at
+	`, authorID)
+}
+
+// HasConflict проверяет, запрещено ли reviewerID ревьюить автора authorID
+func (r *ConflictRepository) HasConflict(reviewerID, authorID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM reviewer_conflicts
+			WHERE (reviewer_id = $1 AND author_id = $2)
+			   OR (mutual AND reviewer_id = $2 AND author_id = $1)
+		)
+	`, reviewerID, authorID).Scan(&exists)
+	return exists, err
+}
+
+// Delete удаляет правило
+func (r *ConflictRepository) Delete(id uuid.UUID) (bool, error) {
+	result, err := r.DB.Exec("DELETE FROM reviewer_conflicts WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *ConflictRepository) query(query string, args ...interface{}) ([]model.ConflictRule, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]model.ConflictRule, 0)
+	for rows.Next() {
+		var c model.ConflictRule
+		if err := rows.Scan(&c.ID, &c.ReviewerID, &c.AuthorID, &c.Mutual, &c.Reason, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"avito-assignment/internal/model"
+	"fmt"
+	"strings"
+)
+
+// likePrefix строит шаблон LIKE для поиска по началу строки без учета регистра,
+// экранируя спецсимволы LIKE во введенном тексте.
+func likePrefix(search string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(search))
+	return escaped + "%"
+}
+
+// List возвращает страницу пользователей, отсортированных по имени.
+// Следующая страница начинается строго после filter.After.
+func (r *UserRepository) List(filter model.UserListFilter) ([]model.User, error) {
+	var conditions []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Search != "" {
+		conditions = append(conditions, "lower(username) LIKE "+arg(likePrefix(filter.Search)))
+	}
+	if filter.TeamID != nil {
+		conditions = append(conditions, "team_id = "+arg(*filter.TeamID))
+	}
+	if filter.IsActive != nil {
+		conditions = append(conditions, "is_active = "+arg(*filter.IsActive))
+	}
+	if filter.After != "" {
+		conditions = append(conditions, "username > "+arg(filter.After))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	query := fmt.Sprintf(`
+		SELECT id, username, team_id, is_active, is_senior, version
+		FROM users
+		%s
+		ORDER BY username
+		LIMIT %s
+	`, where, arg(filter.Limit))
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []model.User{}
+	for rows.Next() {
+		var u model.User
+		if err = rows.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive, &u.IsSenior, &u.Version); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// List возвращает страницу команд, отсортированных по названию, с числом участников.
+// Следующая страница начинается строго после filter.After.
+func (r *TeamRepository) List(filter model.TeamListFilter) ([]model.TeamSummary, error) {
+	var conditions []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Search != "" {
+		conditions = append(conditions, "lower(t.name) LIKE "+arg(likePrefix(filter.Search)))
+	}
+	if filter.After != "" {
+		conditions = append(conditions, "t.name > "+arg(filter.After))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	query := fmt.Sprintf(`
+		SELECT t.id, t.name,
+			(SELECT COUNT(*) FROM users u WHERE u.team_id = t.id),
+			(SELECT COUNT(*) FROM users u WHERE u.team_id = t.id AND u.is_active)
+		FROM teams t
+		%s
+		ORDER BY t.name
+		LIMIT %s
+	`, where, arg(filter.Limit))
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []model.TeamSummary{}
+	for rows.Next() {
+		var t model.TeamSummary
+		if err = rows.Scan(&t.ID, &t.Name, &t.MemberCount, &t.ActiveMemberCount); err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+	return teams, rows.Err()
+}
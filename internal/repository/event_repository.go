@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"avito-assignment/internal/model"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventRepository предоставляет методы для работы с событиями по PR.
+type EventRepository struct {
+	DB *sql.DB
+}
+
+// NewEventRepository создает новый экземпляр EventRepository.
+func NewEventRepository(db *sql.DB) *EventRepository {
+	return &EventRepository{DB: db}
+}
+
+// Create сохраняет событие
+func (r *EventRepository) Create(event *model.PREvent) error {
+	return insertEvent(r.DB, event)
+}
+
+// execer — общий для *sql.DB и *sql.Tx метод выполнения запроса.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertEvent сохраняет событие в базе или в транзакции, где меняется сам PR,
+// чтобы событие записывалось вместе с изменением.
+func insertEvent(db execer, event *model.PREvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	if len(event.Payload) == 0 {
+		event.Payload = []byte(`{}`)
+	}
+
+	query := `
+		INSERT INTO pr_events (id, pr_id, event_type, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := db.Exec(query, event.ID, event.PRID, event.Type, []byte(event.Payload), event.CreatedAt)
+	return err
+}
+
+// GetByPR возвращает события PR в хронологическом порядке
+func (r *EventRepository) GetByPR(prID uuid.UUID) ([]model.PREvent, error) {
+	query := `
+		SELECT id, pr_id, event_type, payload, created_at
+		FROM pr_events
+		WHERE pr_id = $1
+		ORDER BY created_at
+	`
+	rows, err := r.DB.Query(query, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]model.PREvent, 0)
+	for rows.Next() {
+		var e model.PREvent
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.PRID, &e.Type, &payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"avito-assignment/internal/model"
+	"database/sql"
+)
+
+// IdempotencyRepository предоставляет методы для работы с ключами идемпотентности.
+type IdempotencyRepository struct {
+	DB *sql.DB
+}
+
+// NewIdempotencyRepository создает новый экземпляр IdempotencyRepository.
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{DB: db}
+}
+
+// Reserve занимает ключ под новый запрос. Если ключ уже занят и не истек, возвращает
+// false и сохраненную запись. Истекшая запись удаляется и ключ занимается заново.
+// Если занятый ключ успели освободить до чтения записи, возвращает sql.ErrNoRows.
+func (r *IdempotencyRepository) Reserve(record *model.IdempotencyRecord) (bool, *model.IdempotencyRecord, error) {
+	_, err := r.DB.Exec(`
+		DELETE FROM idempotency_keys
+		WHERE key = $1 AND method = $2 AND path = $3 AND expires_at <= now()
+	`, record.Key, record.Method, record.Path)
+	if err != nil {
+		return false, nil, err
+	}
+
+	res, err := r.DB.Exec(`
+		INSERT INTO idempotency_keys (key, method, path, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING
+	`, record.Key, record.Method, record.Path, record.RequestHash, record.ExpiresAt)
+	if err != nil {
+		return false, nil, err
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, nil, err
+	}
+	if inserted == 1 {
+		return true, nil, nil
+	}
+
+	existing := model.IdempotencyRecord{Key: record.Key, Method: record.Method, Path: record.Path}
+	var statusCode sql.NullInt64
+	var contentType sql.NullString
+	err = r.DB.QueryRow(`
+		SELECT request_hash, status_code, content_type, response_body, expires_at
+		FROM idempotency_keys
+		WHERE key = $1 AND method = $2 AND path = $3
+	`, record.Key, record.Method, record.Path).Scan(
+		&existing.RequestHash, &statusCode, &contentType, &existing.Body, &existing.ExpiresAt)
+	if err != nil {
+		return false, nil, err
+	}
+	existing.StatusCode = int(statusCode.Int64)
+	existing.ContentType = contentType.String
+	return false, &existing, nil
+}
+
+// Complete сохраняет ответ на запрос, занявший ключ.
+func (r *IdempotencyRepository) Complete(record *model.IdempotencyRecord) error {
+	_, err := r.DB.Exec(`
+		UPDATE idempotency_keys
+		SET status_code = $4, content_type = $5, response_body = $6
+		WHERE key = $1 AND method = $2 AND path = $3
+	`, record.Key, record.Method, record.Path, record.StatusCode, record.ContentType, record.Body)
+	return err
+}
+
+// Release освобождает ключ, чтобы запрос можно было повторить.
+func (r *IdempotencyRepository) Release(key, method, path string) error {
+	_, err := r.DB.Exec(`
+		DELETE FROM idempotency_keys
+		WHERE key = $1 AND method = $2 AND path = $3
+	`, key, method, path)
+	return err
+}
+
+// DeleteExpired удаляет истекшие ключи и возвращает их число.
+func (r *IdempotencyRepository) DeleteExpired() (int64, error) {
+	res, err := r.DB.Exec(`DELETE FROM idempotency_keys WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"avito-assignment/internal/model"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// GetDependencies возвращает PR, от которых зависит указанный PR.
+func (r *PRRepository) GetDependencies(prID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT depends_on_id
+		FROM pr_dependencies
+		WHERE pr_id = $1
+		ORDER BY created_at
+	`
+	return r.queryIDs(query, prID)
+}
+
+// DependenciesBlockedError возвращается Merge, если у PR остались несмерженные зависимости.
+type DependenciesBlockedError struct {
+	PRIDs []uuid.UUID
+}
+
+func (e *DependenciesBlockedError) Error() string {
+	return "pull request is blocked by unmerged dependencies"
+}
+
+// blockingDependencies возвращает зависимости PR, которые еще не смержены.
+func blockingDependencies(tx *sql.Tx, prID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := tx.Query(`
+		SELECT d.depends_on_id
+		FROM pr_dependencies d
+		JOIN pull_requests pr ON pr.id = d.depends_on_id
+		WHERE d.pr_id = $1 AND pr.status <> 'MERGED'
+		ORDER BY d.created_at
+	`, prID)
+	if err != nil {
+		return nil, err
+	}
+	return scanIDs(rows)
+}
+
+// ErrDependencyCycle возвращается, если новая зависимость замкнула бы цикл.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// AddDependencies добавляет зависимости PR (повторные игнорируются). Проверка на цикл и вставка
+// выполняются в одной транзакции под блокировкой графа зависимостей: иначе два параллельных
+// запроса (A→B и B→A) прошли бы проверку и вместе создали цикл. Если зависимость замыкает цикл,
+// возвращает ErrDependencyCycle и ничего не добавляет.
+func (r *PRRepository) AddDependencies(prID uuid.UUID, dependsOn []uuid.UUID) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	// Цикл может замкнуться через любые PR, поэтому блокировки строк участвующих PR недостаточно:
+	// добавления зависимостей сериализуются целиком до конца транзакции
+	_, err = tx.Exec(`SELECT pg_advisory_xact_lock($1)`, dependencyGraphLock)
+	if err != nil {
+		return err
+	}
+
+	for _, depID := range dependsOn {
+		// Цикл возникает, если зависимость сама (транзитивно) зависит от этого PR
+		cycle, errPath := dependencyPathExists(tx, depID, prID)
+		if errPath != nil {
+			return errPath
+		}
+		if cycle {
+			return ErrDependencyCycle
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO pr_dependencies (pr_id, depends_on_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, prID, depID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = bumpPRVersion(tx, prID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// dependencyGraphLock — ключ advisory-блокировки, сериализующей изменения графа зависимостей PR.
+const dependencyGraphLock = 7_310_001
+
+// dependencyPathExists проверяет, зависит ли fromID (прямо или транзитивно) от toID.
+func dependencyPathExists(tx *sql.Tx, fromID, toID uuid.UUID) (bool, error) {
+	query := `
+		WITH RECURSIVE chain(id) AS (
+			SELECT depends_on_id FROM pr_dependencies WHERE pr_id = $1
+			UNION
+			SELECT d.depends_on_id
+			FROM pr_dependencies d
+			JOIN chain c ON d.pr_id = c.id
+		)
+		SELECT EXISTS(SELECT 1 FROM chain WHERE id = $2)
+	`
+	var exists bool
+	err := tx.QueryRow(query, fromID, toID).Scan(&exists)
+	return exists, err
+}
+
+// notifyUnblockedDependents в транзакции мержа создает событие PR_UNBLOCKED для открытых
+// зависимых PR, у которых не осталось несмерженных зависимостей. Зависимые PR блокируются:
+// при параллельном мерже двух зависимостей вторая транзакция дождется первой и увидит
+// ее мерж, так что событие не потеряется.
+func notifyUnblockedDependents(tx *sql.Tx, mergedID uuid.UUID) error {
+	rows, err := tx.Query(`
+		SELECT pr.id
+		FROM pr_dependencies d
+		JOIN pull_requests pr ON pr.id = d.pr_id
+		WHERE d.depends_on_id = $1 AND pr.status = 'OPEN'
+		ORDER BY pr.id
+		FOR UPDATE OF pr
+	`, mergedID)
+	if err != nil {
+		return err
+	}
+	dependents, err := scanIDs(rows)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]uuid.UUID{"merged_dependency_id": mergedID})
+	if err != nil {
+		return err
+	}
+	for _, dependentID := range dependents {
+		var blocked bool
+		err = tx.QueryRow(`
+			SELECT EXISTS(
+				SELECT 1
+				FROM pr_dependencies d
+				JOIN pull_requests pr ON pr.id = d.depends_on_id
+				WHERE d.pr_id = $1 AND pr.status <> 'MERGED'
+			)
+		`, dependentID).Scan(&blocked)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			continue
+		}
+
+		err = insertEvent(tx, &model.PREvent{PRID: dependentID, Type: model.EventPRUnblocked, Payload: payload})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryIDs выполняет запрос, возвращающий одну колонку UUID.
+func (r *PRRepository) queryIDs(query string, args ...interface{}) ([]uuid.UUID, error) {
+	return queryIDs(r.DB, query, args...)
+}
+
+// queryIDs выполняет запрос, возвращающий одну колонку UUID.
+func queryIDs(db *sql.DB, query string, args ...interface{}) ([]uuid.UUID, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanIDs(rows)
+}
+
+// scanIDs читает одну колонку UUID и закрывает rows.
+func scanIDs(rows *sql.Rows) ([]uuid.UUID, error) {
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
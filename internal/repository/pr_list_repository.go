@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"avito-assignment/internal/model"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// prSortColumns сопоставляет полю сортировки колонку и тип значения курсора.
+// Под каждую пару (колонка, id) есть индекс, поэтому страница читается по индексу.
+var prSortColumns = map[model.PRSortField]struct {
+	column string
+	cast   string
+}{
+	model.SortByCreatedAt: {"pr.created_at", "timestamptz"},
+	model.SortByPriority:  {"pr.priority", "pr_priority"},
+	model.SortByName:      {"pr.pull_request_name", "text"},
+}
+
+// List возвращает страницу PR по фильтру. Страницы разбиваются по ключу (поле сортировки, id):
+// следующая страница начинается строго после filter.After, без OFFSET.
+func (r *PRRepository) List(filter model.PRListFilter) ([]model.PullRequest, error) {
+	sortColumn, ok := prSortColumns[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", filter.Sort)
+	}
+
+	var conditions []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Status != nil {
+		conditions = append(conditions, "pr.status = "+arg(*filter.Status))
+	}
+	if filter.AuthorID != nil {
+		conditions = append(conditions, "pr.author_id = "+arg(*filter.AuthorID))
+	}
+	if filter.RepositoryID != nil {
+		conditions = append(conditions, "pr.repository_id = "+arg(*filter.RepositoryID))
+	}
+	if filter.ReviewerID != nil {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM pr_reviewers rr WHERE rr.pr_id = pr.id AND rr.reviewer_id = `+arg(*filter.ReviewerID)+`
+		)`)
+	}
+	if filter.TeamID != nil {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM pr_review_teams rt WHERE rt.pr_id = pr.id AND rt.team_id = `+arg(*filter.TeamID)+`
+		)`)
+	}
+	if filter.Label != "" {
+		conditions = append(conditions, "pr.labels @> "+arg(pq.StringArray{filter.Label})+"::text[]")
+	}
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "pr.created_at >= "+arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "pr.created_at < "+arg(*filter.CreatedTo))
+	}
+	if filter.MergedFrom != nil {
+		conditions = append(conditions, "pr.merged_at >= "+arg(*filter.MergedFrom))
+	}
+	if filter.MergedTo != nil {
+		conditions = append(conditions, "pr.merged_at < "+arg(*filter.MergedTo))
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if filter.After != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s, pr.id) %s (%s::%s, %s)",
+			sortColumn.column, comparison, arg(filter.After.Value), sortColumn.cast, arg(filter.After.ID)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, "\n\t\t  AND ")
+	}
+	query := fmt.Sprintf(`
+		SELECT pr.id, pr.external_id, pr.pull_request_name, pr.author_id, pr.repository_id, pr.required_reviewers, pr.lines_added, pr.lines_removed, pr.files_changed, pr.high_risk, pr.priority, pr.review_due_at, pr.labels, pr.version, pr.status, pr.created_at, pr.merged_at
+		FROM pull_requests pr
+		%s
+		ORDER BY %s %s, pr.id %s
+		LIMIT %s
+	`, where, sortColumn.column, direction, direction, arg(filter.Limit))
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prs := []model.PullRequest{}
+	for rows.Next() {
+		var pr model.PullRequest
+		err = rows.Scan(&pr.ID, &pr.ExternalID, &pr.Title, &pr.AuthorID, &pr.RepositoryID, &pr.RequiredReviewers, &pr.DiffStats.LinesAdded, &pr.DiffStats.LinesRemoved, &pr.DiffStats.FilesChanged, &pr.DiffStats.HighRisk, &pr.Priority, &pr.ReviewDueAt, (*pq.StringArray)(&pr.Labels), &pr.Version, &pr.Status, &pr.CreatedAt, &pr.MergedAt)
+		if err != nil {
+			return nil, err
+		}
+		prs = append(prs, pr)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Связанные данные загружаются после закрытия курсора, чтобы не держать два соединения на запрос
+	if err = r.loadRelations(prs); err != nil {
+		return nil, err
+	}
+	return prs, nil
+}
+
+// loadRelations загружает ревьюверов всех ролей, зависимости, команды ревью и состояние одобрений
+// сразу для всех PR из prs: число запросов не зависит от размера страницы.
+func (r *PRRepository) loadRelations(prs []model.PullRequest) error {
+	if len(prs) == 0 {
+		return nil
+	}
+	prIDs := make([]uuid.UUID, 0, len(prs))
+	for _, pr := range prs {
+		prIDs = append(prIDs, pr.ID)
+	}
+
+	reviewers := make(map[model.ReviewerRole]map[uuid.UUID][]uuid.UUID, 3)
+	for _, role := range []model.ReviewerRole{model.RoleRequired, model.RoleOptional, model.RoleShadow} {
+		byPR, err := r.queryIDsByPR(reviewersByRoleBatchQuery, prIDs, role)
+		if err != nil {
+			return err
+		}
+		reviewers[role] = byPR
+	}
+	dependencies, err := r.queryIDsByPR(`
+		SELECT pr_id, depends_on_id
+		FROM pr_dependencies
+		WHERE pr_id = ANY($1::uuid[])
+		ORDER BY created_at
+	`, prIDs)
+	if err != nil {
+		return err
+	}
+	reviewTeams, err := r.queryIDsByPR(`
+		SELECT pr_id, team_id
+		FROM pr_review_teams
+		WHERE pr_id = ANY($1::uuid[])
+		ORDER BY team_id
+	`, prIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range prs {
+		pr := &prs[i]
+		pr.Reviewers = reviewers[model.RoleRequired][pr.ID]
+		pr.OptionalReviewers = reviewers[model.RoleOptional][pr.ID]
+		pr.ShadowReviewers = reviewers[model.RoleShadow][pr.ID]
+		pr.DependsOn = dependencies[pr.ID]
+		pr.ReviewTeamIDs = reviewTeams[pr.ID]
+	}
+	return r.loadReviewState(prs, prIDs)
+}
+
+// queryIDsByPR выполняет запрос, возвращающий пары (pr_id, UUID), и группирует UUID по PR
+// в порядке строк результата. Первым аргументом запроса передается список prIDs.
+func (r *PRRepository) queryIDsByPR(query string, prIDs []uuid.UUID, args ...interface{}) (map[uuid.UUID][]uuid.UUID, error) {
+	args = append([]interface{}{pq.StringArray(uuidStrings(prIDs))}, args...)
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byPR := make(map[uuid.UUID][]uuid.UUID, len(prIDs))
+	for rows.Next() {
+		var prID, id uuid.UUID
+		if err := rows.Scan(&prID, &id); err != nil {
+			return nil, err
+		}
+		byPR[prID] = append(byPR[prID], id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return byPR, nil
+}
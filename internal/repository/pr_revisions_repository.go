@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"avito-assignment/internal/model"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// loadReviewState заполняет номер текущей ревизии, список одобривших ее ревьюверов
+// и целевые команды кросс-командного PR для всех PR из prs (prIDs — их идентификаторы).
+func (r *PRRepository) loadReviewState(prs []model.PullRequest, prIDs []uuid.UUID) error {
+	rows, err := r.DB.Query(`
+		SELECT pr_id, MAX(number)
+		FROM pr_revisions
+		WHERE pr_id = ANY($1::uuid[])
+		GROUP BY pr_id
+	`, pq.StringArray(uuidStrings(prIDs)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	revisions := make(map[uuid.UUID]int, len(prIDs))
+	for rows.Next() {
+		var prID uuid.UUID
+		var number int
+		if err = rows.Scan(&prID, &number); err != nil {
+			return err
+		}
+		revisions[prID] = number
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	approvedBy, err := r.queryIDsByPR(`
+		SELECT pr_id, reviewer_id
+		FROM pr_reviewers
+		WHERE pr_id = ANY($1::uuid[]) AND decision = 'APPROVED'
+		ORDER BY decided_at
+	`, prIDs)
+	if err != nil {
+		return err
+	}
+	targetTeams, err := r.queryIDsByPR(`
+		SELECT pr_id, team_id
+		FROM pr_review_teams
+		WHERE pr_id = ANY($1::uuid[]) AND approval_required
+		ORDER BY team_id
+	`, prIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range prs {
+		pr := &prs[i]
+		// У PR без записанных ревизий текущей считается первая
+		pr.Revision = 1
+		if number, ok := revisions[pr.ID]; ok {
+			pr.Revision = number
+		}
+		pr.ApprovedBy = approvedBy[pr.ID]
+		pr.TargetTeamIDs = targetTeams[pr.ID]
+	}
+	return nil
+}
+
+// ApprovalsMissingError возвращается Merge, если от части целевых команд PR еще нет
+// ни одного одобрения обязательного ревьювера.
+type ApprovalsMissingError struct {
+	TeamIDs []uuid.UUID
+}
+
+func (e *ApprovalsMissingError) Error() string {
+	return "pull request is missing approvals from target teams"
+}
+
+// teamsMissingApproval возвращает целевые команды PR, от которых еще нет ни одного
+// одобрения обязательного ревьювера.
+func teamsMissingApproval(tx *sql.Tx, prID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := tx.Query(`
+		SELECT t.team_id
+		FROM pr_review_teams t
+		WHERE t.pr_id = $1 AND t.approval_required
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM pr_reviewers rr
+			JOIN users u ON u.id = rr.reviewer_id
+			WHERE rr.pr_id = t.pr_id AND rr.role = 'REQUIRED'
+			  AND rr.decision = 'APPROVED' AND u.team_id = t.team_id
+		  )
+		ORDER BY t.team_id
+	`, prID)
+	if err != nil {
+		return nil, err
+	}
+	return scanIDs(rows)
+}
+
+// ApproveReview отмечает решение ревьювера как APPROVED и в той же транзакции записывает
+// событие REVIEW_APPROVED с номером текущей ревизии. Возвращает false, если пользователь
+// не назначен ревьювером PR, и sql.ErrNoRows, если PR уже не открыт.
+func (r *PRRepository) ApproveReview(prID, reviewerID uuid.UUID) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM pull_requests WHERE id = $1 FOR UPDATE`, prID).Scan(&status)
+	if err != nil {
+		return false, err
+	}
+	if status != "OPEN" {
+		return false, sql.ErrNoRows
+	}
+
+	result, err := tx.Exec(`
+		UPDATE pr_reviewers
+		SET decision = 'APPROVED', decided_at = $1
+		WHERE pr_id = $2 AND reviewer_id = $3
+	`, time.Now(), prID, reviewerID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	var revision int
+	err = tx.QueryRow(`SELECT COALESCE(MAX(number), 1) FROM pr_revisions WHERE pr_id = $1`, prID).Scan(&revision)
+	if err != nil {
+		return false, err
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"reviewer_id": reviewerID,
+		"revision":    revision,
+	})
+	if err != nil {
+		return false, err
+	}
+	err = insertEvent(tx, &model.PREvent{PRID: prID, Type: model.EventReviewApproved, Payload: payload})
+	if err != nil {
+		return false, err
+	}
+	if err = bumpPRVersion(tx, prID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// CreateRevision записывает новую ревизию PR и, если resetApprovals, переводит
+// все одобрения в PENDING. Строка PR блокируется, чтобы параллельные пуши получили
+// разные номера, а события ревизии записываются в той же транзакции.
+// Возвращает sql.ErrNoRows, если PR не открыт.
+func (r *PRRepository) CreateRevision(prID uuid.UUID, commitSHA *string, resetApprovals bool) (*model.PRRevision, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM pull_requests WHERE id = $1 FOR UPDATE`, prID).Scan(&status)
+	if err != nil {
+		return nil, err
+	}
+	if status != "OPEN" {
+		return nil, sql.ErrNoRows
+	}
+
+	revision := &model.PRRevision{
+		ID:                uuid.New(),
+		PRID:              prID,
+		CommitSHA:         commitSHA,
+		ApprovalsReset:    resetApprovals,
+		RereviewRequested: make([]uuid.UUID, 0),
+		CreatedAt:         time.Now(),
+	}
+	err = tx.QueryRow(`
+		SELECT COALESCE(MAX(number), 1) + 1
+		FROM pr_revisions
+		WHERE pr_id = $1
+	`, prID).Scan(&revision.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO pr_revisions (id, pr_id, number, commit_sha, approvals_reset, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, revision.ID, prID, revision.Number, commitSHA, resetApprovals, revision.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if resetApprovals {
+		rows, errQuery := tx.Query(`
+			UPDATE pr_reviewers
+			SET decision = 'PENDING', decided_at = NULL
+			WHERE pr_id = $1 AND decision = 'APPROVED'
+			RETURNING reviewer_id
+		`, prID)
+		if errQuery != nil {
+			return nil, errQuery
+		}
+		for rows.Next() {
+			var id uuid.UUID
+			if err = rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			revision.RereviewRequested = append(revision.RereviewRequested, id)
+		}
+		if err = rows.Err(); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rows.Close()
+	}
+
+	if err = insertRevisionEvents(tx, revision); err != nil {
+		return nil, err
+	}
+	if err = bumpPRVersion(tx, prID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// insertRevisionEvents записывает в транзакции событие PR_REVISION_PUSHED и по событию
+// REVIEW_REREQUESTED для каждого ревьювера, чье одобрение сброшено.
+func insertRevisionEvents(tx *sql.Tx, revision *model.PRRevision) error {
+	payload, err := json.Marshal(revision)
+	if err != nil {
+		return err
+	}
+	err = insertEvent(tx, &model.PREvent{
+		PRID:      revision.PRID,
+		Type:      model.EventRevisionPushed,
+		Payload:   payload,
+		CreatedAt: revision.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, reviewerID := range revision.RereviewRequested {
+		payload, err = json.Marshal(map[string]interface{}{
+			"reviewer_id": reviewerID,
+			"revision":    revision.Number,
+		})
+		if err != nil {
+			return err
+		}
+		err = insertEvent(tx, &model.PREvent{
+			PRID:    revision.PRID,
+			Type:    model.EventReviewRerequested,
+			Payload: payload,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
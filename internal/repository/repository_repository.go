@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"avito-assignment/internal/model"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RepositoryRepository предоставляет методы для работы с репозиториями (кодовыми базами).
+type RepositoryRepository struct {
+	DB *sql.DB
+}
+
+// NewRepositoryRepository создает новый экземпляр RepositoryRepository.
+func NewRepositoryRepository(db *sql.DB) *RepositoryRepository {
+	return &RepositoryRepository{DB: db}
+}
+
+// Create создает репозиторий вместе с командами-владельцами
+func (r *RepositoryRepository) Create(repo *model.Repository) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := `
+		INSERT INTO repositories (id, name, required_reviewers, assignment_strategy, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err = tx.Exec(query, repo.ID, repo.Name, repo.RequiredReviewers, repo.AssignmentStrategy, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err = replaceOwnerTeams(tx, repo.ID, repo.OwnerTeamIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetByID возвращает репозиторий по ID
+func (r *RepositoryRepository) GetByID(id uuid.UUID) (*model.Repository, error) {
+	query := `
+		SELECT id, name, required_reviewers, assignment_strategy
+		FROM repositories
+		WHERE id = $1
+	`
+	var repo model.Repository
+	err := r.DB.QueryRow(query, id).Scan(&repo.ID, &repo.Name, &repo.RequiredReviewers, &repo.AssignmentStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.OwnerTeamIDs, err = r.GetOwnerTeams(id)
+	if err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// GetByName возвращает репозиторий по имени
+func (r *RepositoryRepository) GetByName(name string) (*model.Repository, error) {
+	query := `
+		SELECT id
+		FROM repositories
+		WHERE name = $1
+	`
+	var id uuid.UUID
+	if err := r.DB.QueryRow(query, name).Scan(&id); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// GetAll возвращает все репозитории
+func (r *RepositoryRepository) GetAll() ([]model.Repository, error) {
+	query := `
+		SELECT id, name, required_reviewers, assignment_strategy
+		FROM repositories
+		ORDER BY name
+	`
+	rows, err := r.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	repos := make([]model.Repository, 0)
+	for rows.Next() {
+		var repo model.Repository
+		if err := rows.Scan(&repo.ID, &repo.Name, &repo.RequiredReviewers, &repo.AssignmentStrategy); err != nil {
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range repos {
+		repos[i].OwnerTeamIDs, err = r.GetOwnerTeams(repos[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return repos, nil
+}
+
+// GetOwnerTeams возвращает команды-владельцы репозитория
+func (r *RepositoryRepository) GetOwnerTeams(repoID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.DB.Query(`
+		SELECT team_id
+		FROM repository_teams
+		WHERE repository_id = $1
+		ORDER BY team_id
+	`, repoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teamIDs := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var teamID uuid.UUID
+		if err := rows.Scan(&teamID); err != nil {
+			return nil, err
+		}
+		teamIDs = append(teamIDs, teamID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return teamIDs, nil
+}
+
+// Update обновляет репозиторий и полностью заменяет список команд-владельцев
+func (r *RepositoryRepository) Update(repo *model.Repository) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	query := `
+		UPDATE repositories
+		SET name = $1, required_reviewers = $2, assignment_strategy = $3
+		WHERE id = $4
+	`
+	_, err = tx.Exec(query, repo.Name, repo.RequiredReviewers, repo.AssignmentStrategy, repo.ID)
+	if err != nil {
+		return err
+	}
+
+	if err = replaceOwnerTeams(tx, repo.ID, repo.OwnerTeamIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete удаляет репозиторий и сообщает, существовал ли он.
+// Если к репозиторию относятся PR, возвращается ошибка внешнего ключа.
+func (r *RepositoryRepository) Delete(id uuid.UUID) (bool, error) {
+	result, err := r.DB.Exec("DELETE FROM repositories WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// replaceOwnerTeams заменяет команды-владельцы репозитория в рамках транзакции.
+func replaceOwnerTeams(tx *sql.Tx, repoID uuid.UUID, teamIDs []uuid.UUID) error {
+	_, err := tx.Exec("DELETE FROM repository_teams WHERE repository_id = $1", repoID)
+	if err != nil {
+		return err
+	}
+
+	for _, teamID := range teamIDs {
+		_, err = tx.Exec(`
+			INSERT INTO repository_teams (repository_id, team_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, repoID, teamID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetReviewTeams возвращает команды, из которых набираются ревьюверы PR.
+func (r *PRRepository) GetReviewTeams(prID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT team_id
+		FROM pr_review_teams
+		WHERE pr_id = $1
+		ORDER BY team_id
+	`
+	return r.queryIDs(query, prID)
+}
+
+// GetUnderstaffedByTeam возвращает открытые PR команды, у которых ревьюверов меньше требуемого,
+// от самых старых к новым.
+func (r *PRRepository) GetUnderstaffedByTeam(teamID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT pr.id
+		FROM pull_requests pr
+		JOIN pr_review_teams t ON t.pr_id = pr.id
+		WHERE t.team_id = $1
+		  AND pr.status = 'OPEN'
+		  AND pr.required_reviewers > (
+			SELECT COUNT(*) FROM pr_reviewers rr WHERE rr.pr_id = pr.id AND rr.role = 'REQUIRED'
+		  )
+		ORDER BY pr.created_at
+	`
+	return r.queryIDs(query, teamID)
+}
+
+// ErrAlreadyReviewer возвращается ClaimReviewSlot, если пользователь уже назначен на PR в любой роли.
+var ErrAlreadyReviewer = errors.New("user is already a reviewer")
+
+// ErrReviewerAtCapacity возвращается ClaimReviewSlot, если у пользователя уже максимум открытых ревью.
+var ErrReviewerAtCapacity = errors.New("reviewer has reached the open review limit")
+
+// ClaimReviewSlot назначает пользователя ревьювером на свободное место в PR.
+// Строки ревьювера и PR блокируются (SELECT ... FOR UPDATE), поэтому два параллельных запроса
+// не могут занять одно и то же место, а ревьювер — превысить maxOpenReviews
+// (число открытых PR, где он обязательный ревьювер; 0 — без ограничения).
+// Возвращает false, если PR не открыт или свободных мест нет, ErrAlreadyReviewer —
+// если пользователь уже назначен на PR, ErrReviewerAtCapacity — если лимит исчерпан.
+func (r *PRRepository) ClaimReviewSlot(prID, userID uuid.UUID, maxOpenReviews int) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	// Блокировка ревьювера сериализует его параллельные claim на разные PR
+	_, err = tx.Exec(`SELECT 1 FROM users WHERE id = $1 FOR NO KEY UPDATE`, userID)
+	if err != nil {
+		return false, err
+	}
+
+	var status string
+	var required int
+	err = tx.QueryRow(`
+		SELECT status, required_reviewers
+		FROM pull_requests
+		WHERE id = $1
+		FOR UPDATE
+	`, prID).Scan(&status, &required)
+	if err != nil {
+		return false, err
+	}
+	if status != "OPEN" {
+		return false, nil
+	}
+
+	var assigned int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM pr_reviewers WHERE pr_id = $1 AND role = 'REQUIRED'`, prID).Scan(&assigned)
+	if err != nil {
+		return false, err
+	}
+	if assigned >= required {
+		return false, nil
+	}
+
+	if maxOpenReviews > 0 {
+		var load int
+		err = tx.QueryRow(`
+			SELECT COUNT(*)
+			FROM pr_reviewers rr
+			JOIN pull_requests pr ON pr.id = rr.pr_id
+			WHERE rr.reviewer_id = $1 AND rr.role = 'REQUIRED' AND pr.status = 'OPEN'
+		`, userID).Scan(&load)
+		if err != nil {
+			return false, err
+		}
+		if load >= maxOpenReviews {
+			return false, ErrReviewerAtCapacity
+		}
+	}
+
+	// Пользователь мог уже быть назначен опциональным или теневым ревьювером
+	result, err := tx.Exec(`
+		INSERT INTO pr_reviewers (pr_id, reviewer_id, assigned_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (pr_id, reviewer_id) DO NOTHING
+	`, prID, userID, time.Now())
+	if err != nil {
+		return false, err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if inserted == 0 {
+		return false, ErrAlreadyReviewer
+	}
+
+	if err = bumpPRVersion(tx, prID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"avito-assignment/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// GetReviewQueue возвращает открытые PR, по которым пользователь еще не принял решение,
+// вместе с автором, признаком заблокированного автора и состоянием остальных ревьюверов.
+func (r *PRRepository) GetReviewQueue(userID uuid.UUID) ([]model.ReviewQueueEntry, error) {
+	query := `
+		SELECT pr.id, pr.pull_request_name, pr.author_id, a.username, rr.role, pr.priority,
+		       pr.created_at, pr.review_due_at,
+		       EXISTS (
+				SELECT 1
+				FROM pr_dependencies d
+				JOIN pull_requests dep ON dep.id = d.pr_id
+				WHERE d.depends_on_id = pr.id AND dep.status = 'OPEN'
+				  AND dep.author_id = pr.author_id
+		       ) as author_blocked
+		FROM pr_reviewers rr
+		JOIN pull_requests pr ON pr.id = rr.pr_id
+		JOIN users a ON a.id = pr.author_id
+		WHERE rr.reviewer_id = $1 AND rr.decision = 'PENDING' AND pr.status = 'OPEN'
+		ORDER BY pr.created_at
+	`
+	rows, err := r.DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]model.ReviewQueueEntry, 0)
+	for rows.Next() {
+		var e model.ReviewQueueEntry
+		err = rows.Scan(&e.PRID, &e.Title, &e.AuthorID, &e.AuthorUsername, &e.Role, &e.Priority,
+			&e.CreatedAt, &e.ReviewDueAt, &e.AuthorBlocked)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	prIDs := make([]uuid.UUID, len(entries))
+	for i := range entries {
+		prIDs[i] = entries[i].PRID
+	}
+	states, err := r.GetReviewerStates(prIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].OtherReviewers = states[entries[i].PRID]
+		if entries[i].OtherReviewers == nil {
+			entries[i].OtherReviewers = make([]model.ReviewerState, 0)
+		}
+	}
+	return entries, nil
+}
+
+// GetReviewerStates возвращает ревьюверов PR из prIDs (кроме excludeID) с их ролями и решениями,
+// сгруппированных по PR, одним запросом.
+func (r *PRRepository) GetReviewerStates(prIDs []uuid.UUID, excludeID uuid.UUID) (map[uuid.UUID][]model.ReviewerState, error) {
+	states := make(map[uuid.UUID][]model.ReviewerState, len(prIDs))
+	if len(prIDs) == 0 {
+		return states, nil
+	}
+
+	query := `
+		SELECT rr.pr_id, rr.reviewer_id, u.username, rr.role, rr.decision
+		FROM pr_reviewers rr
+		JOIN users u ON u.id = rr.reviewer_id
+		WHERE rr.pr_id = ANY($1::uuid[]) AND rr.reviewer_id != $2
+		ORDER BY rr.pr_id, rr.assigned_at
+	`
+	rows, err := r.DB.Query(query, pq.StringArray(uuidStrings(prIDs)), excludeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var prID uuid.UUID
+		var st model.ReviewerState
+		if err := rows.Scan(&prID, &st.UserID, &st.Username, &st.Role, &st.Decision); err != nil {
+			return nil, err
+		}
+		states[prID] = append(states[prID], st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
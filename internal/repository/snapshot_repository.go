@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"avito-assignment/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrSnapshotConflict возвращается при загрузке с политикой fail, если запись уже есть в базе.
+var ErrSnapshotConflict = errors.New("snapshot record already exists")
+
+// SnapshotSink принимает записи выгрузки: Section вызывается перед каждым разделом,
+// Record — для каждой записи раздела.
+type SnapshotSink interface {
+	Section(section model.SnapshotSection) error
+	Record(record interface{}) error
+}
+
+// SnapshotRepository выгружает и загружает данные сервиса целиком.
+type SnapshotRepository struct {
+	DB *sql.DB
+}
+
+// NewSnapshotRepository создает новый экземпляр SnapshotRepository.
+func NewSnapshotRepository(db *sql.DB) *SnapshotRepository {
+	return &SnapshotRepository{DB: db}
+}
+
+// snapshotExports описывает запросы выгрузки разделов в порядке model.SnapshotSections.
+var snapshotExports = []struct {
+	section model.SnapshotSection
+	query   string
+	scan    func(rows *sql.Rows) (interface{}, error)
+}{
+	{model.SectionTeams, `
+		SELECT id, name, shadowing_enabled, reset_approvals_on_revision, created_at
+		FROM teams
+		ORDER BY created_at, id
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var t model.SnapshotTeam
+		err := rows.Scan(&t.ID, &t.Name, &t.ShadowingEnabled, &t.ResetApprovalsOnRevision, &t.CreatedAt)
+		return t, err
+	}},
+	{model.SectionRepositories, `
+		SELECT r.id, r.name, r.required_reviewers, r.assignment_strategy,
+			ARRAY(SELECT rt.team_id::text FROM repository_teams rt WHERE rt.repository_id = r.id ORDER BY rt.team_id),
+			r.created_at
+		FROM repositories r
+		ORDER BY r.created_at, r.id
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var repo model.SnapshotRepository
+		var owners pq.StringArray
+		err := rows.Scan(&repo.ID, &repo.Name, &repo.RequiredReviewers, &repo.AssignmentStrategy, &owners, &repo.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		repo.OwnerTeamIDs = make([]uuid.UUID, 0, len(owners))
+		for _, owner := range owners {
+			teamID, err := uuid.Parse(owner)
+			if err != nil {
+				return nil, err
+			}
+			repo.OwnerTeamIDs = append(repo.OwnerTeamIDs, teamID)
+		}
+		return repo, nil
+	}},
+	{model.SectionUsers, `
+		SELECT id, username, team_id, is_active, is_senior, created_at
+		FROM users
+		ORDER BY created_at, id
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var u model.SnapshotUser
+		err := rows.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive, &u.IsSenior, &u.CreatedAt)
+		return u, err
+	}},
+	{model.SectionShadowReviewers, `
+		SELECT team_id, user_id, last_assigned_at
+		FROM team_shadow_reviewers
+		ORDER BY team_id, user_id
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var sr model.SnapshotShadowReviewer
+		err := rows.Scan(&sr.TeamID, &sr.UserID, &sr.LastAssignedAt)
+		return sr, err
+	}},
+	{model.SectionReviewerTiers, `
+		SELECT team_id, min_lines, high_risk, reviewers, senior_reviewers
+		FROM team_reviewer_tiers
+		ORDER BY team_id, min_lines, high_risk
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var t model.SnapshotReviewerTier
+		err := rows.Scan(&t.TeamID, &t.MinLines, &t.HighRisk, &t.Reviewers, &t.SeniorReviewers)
+		return t, err
+	}},
+	{model.SectionReviewerConflicts, `
+		SELECT id, reviewer_id, author_id, mutual, reason, created_at
+		FROM reviewer_conflicts
+		ORDER BY created_at, id
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var c model.SnapshotReviewerConflict
+		err := rows.Scan(&c.ID, &c.ReviewerID, &c.AuthorID, &c.Mutual, &c.Reason, &c.CreatedAt)
+		return c, err
+	}},
+	{model.SectionPullRequests, `
+		SELECT id, external_id, pull_request_name, author_id, repository_id, required_reviewers,
+			lines_added, lines_removed, files_changed, high_risk, priority, review_due_at, labels,
+			status, created_at, merged_at
+		FROM pull_requests
+		ORDER BY created_at, id
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var p model.SnapshotPullRequest
+		err := rows.Scan(&p.ID, &p.ExternalID, &p.Title, &p.AuthorID, &p.RepositoryID, &p.RequiredReviewers,
+			&p.LinesAdded, &p.LinesRemoved, &p.FilesChanged, &p.HighRisk, &p.Priority, &p.ReviewDueAt,
+			(*pq.StringArray)(&p.Labels), &p.Status, &p.CreatedAt, &p.MergedAt)
+		return p, err
+	}},
+	{model.SectionReviewTeams, `
+		SELECT pr_id, team_id, approval_required
+		FROM pr_review_teams
+		ORDER BY pr_id, team_id
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var t model.SnapshotReviewTeam
+		err := rows.Scan(&t.PRID, &t.TeamID, &t.ApprovalRequired)
+		return t, err
+	}},
+	{model.SectionDependencies, `
+		SELECT pr_id, depends_on_id, created_at
+		FROM pr_dependencies
+		ORDER BY pr_id, created_at, depends_on_id
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var d model.SnapshotDependency
+		err := rows.Scan(&d.PRID, &d.DependsOnID, &d.CreatedAt)
+		return d, err
+	}},
+	{model.SectionExcludedReviewers, `
+		SELECT pr_id, user_id
+		FROM pr_excluded_reviewers
+		ORDER BY pr_id, user_id
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var e model.SnapshotExcludedReviewer
+		err := rows.Scan(&e.PRID, &e.UserID)
+		return e, err
+	}},
+	{model.SectionAssignments, `
+		SELECT pr_id, reviewer_id, role, decision, assigned_at, decided_at
+		FROM pr_reviewers
+		ORDER BY pr_id, assigned_at, reviewer_id
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var a model.SnapshotAssignment
+		err := rows.Scan(&a.PRID, &a.ReviewerID, &a.Role, &a.Decision, &a.AssignedAt, &a.DecidedAt)
+		return a, err
+	}},
+	{model.SectionRevisions, `
+		SELECT id, pr_id, number, commit_sha, approvals_reset, created_at
+		FROM pr_revisions
+		ORDER BY pr_id, number
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var rev model.SnapshotRevision
+		err := rows.Scan(&rev.ID, &rev.PRID, &rev.Number, &rev.CommitSHA, &rev.ApprovalsReset, &rev.CreatedAt)
+		return rev, err
+	}},
+	{model.SectionReviewerChanges, `
+		SELECT id, pr_id, old_reviewer_id, new_reviewer_id, kind, reason, created_at
+		FROM pr_reviewer_changes
+		ORDER BY created_at, id
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var c model.SnapshotReviewerChange
+		err := rows.Scan(&c.ID, &c.PRID, &c.OldReviewerID, &c.NewReviewerID, &c.Kind, &c.Reason, &c.CreatedAt)
+		return c, err
+	}},
+	{model.SectionEvents, `
+		SELECT id, pr_id, event_type, payload, created_at
+		FROM pr_events
+		ORDER BY created_at, id
+	`, func(rows *sql.Rows) (interface{}, error) {
+		var e model.SnapshotEvent
+		var payload []byte
+		err := rows.Scan(&e.ID, &e.PRID, &e.Type, &payload, &e.CreatedAt)
+		e.Payload = payload
+		return e, err
+	}},
+}
+
+// Export передает в sink все разделы выгрузки. Разделы читаются в одной транзакции
+// REPEATABLE READ, поэтому выгрузка согласована, даже если данные меняются во время чтения.
+func (r *SnapshotRepository) Export(sink SnapshotSink) error {
+	tx, err := r.DB.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	for _, export := range snapshotExports {
+		if err = sink.Section(export.section); err != nil {
+			return err
+		}
+		if err = exportSection(tx, sink, export.query, export.scan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportSection(tx *sql.Tx, sink SnapshotSink, query string, scan func(rows *sql.Rows) (interface{}, error)) error {
+	rows, err := tx.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		if err = sink.Record(record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// SnapshotImport — загрузка выгрузки в одной транзакции. Завершается Commit или Rollback.
+type SnapshotImport struct {
+	tx     *sql.Tx
+	policy model.ImportPolicy
+}
+
+// BeginImport начинает загрузку с заданной политикой конфликтов.
+func (r *SnapshotRepository) BeginImport(policy model.ImportPolicy) (*SnapshotImport, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &SnapshotImport{tx: tx, policy: policy}, nil
+}
+
+// Commit фиксирует загрузку.
+func (i *SnapshotImport) Commit() error {
+	return i.tx.Commit()
+}
+
+// Rollback отменяет загрузку.
+func (i *SnapshotImport) Rollback() error {
+	return i.tx.Rollback()
+}
+
+// Team загружает команду.
+func (i *SnapshotImport) Team(t model.SnapshotTeam) (model.ImportOutcome, error) {
+	return i.insert("teams", []string{"id", "name", "shadowing_enabled", "reset_approvals_on_revision", "created_at"},
+		[]string{"id"}, t.ID, t.Name, t.ShadowingEnabled, t.ResetApprovalsOnRevision, t.CreatedAt)
+}
+
+// Repository загружает репозиторий. Если запись загружена, команды-владельцы
+// заменяются командами из выгрузки.
+func (i *SnapshotImport) Repository(repo model.SnapshotRepository) (model.ImportOutcome, error) {
+	outcome, err := i.insert("repositories", []string{"id", "name", "required_reviewers", "assignment_strategy", "created_at"},
+		[]string{"id"}, repo.ID, repo.Name, repo.RequiredReviewers, repo.AssignmentStrategy, repo.CreatedAt)
+	if err != nil || outcome == model.ImportSkipped {
+		return outcome, err
+	}
+	return outcome, replaceOwnerTeams(i.tx, repo.ID, repo.OwnerTeamIDs)
+}
+
+// User загружает пользователя.
+func (i *SnapshotImport) User(u model.SnapshotUser) (model.ImportOutcome, error) {
+	return i.insert("users", []string{"id", "username", "team_id", "is_active", "is_senior", "created_at"},
+		[]string{"id"}, u.ID, u.Username, u.TeamID, u.IsActive, u.IsSenior, u.CreatedAt)
+}
+
+// ShadowReviewer загружает теневого ревьювера команды.
+func (i *SnapshotImport) ShadowReviewer(sr model.SnapshotShadowReviewer) (model.ImportOutcome, error) {
+	return i.insert("team_shadow_reviewers", []string{"team_id", "user_id", "last_assigned_at"},
+		[]string{"team_id", "user_id"}, sr.TeamID, sr.UserID, sr.LastAssignedAt)
+}
+
+// ReviewerTier загружает уровень числа ревьюверов команды. Уровень определяется командой,
+// размером и риском PR, поэтому его id не выгружается и при загрузке создается заново.
+func (i *SnapshotImport) ReviewerTier(t model.SnapshotReviewerTier) (model.ImportOutcome, error) {
+	return i.insert("team_reviewer_tiers", []string{"team_id", "min_lines", "high_risk", "reviewers", "senior_reviewers"},
+		[]string{"team_id", "min_lines", "high_risk"}, t.TeamID, t.MinLines, t.HighRisk, t.Reviewers, t.SeniorReviewers)
+}
+
+// ReviewerConflict загружает правило конфликта интересов.
+func (i *SnapshotImport) ReviewerConflict(c model.SnapshotReviewerConflict) (model.ImportOutcome, error) {
+	return i.insert("reviewer_conflicts", []string{"id", "reviewer_id", "author_id", "mutual", "reason", "created_at"},
+		[]string{"id"}, c.ID, c.ReviewerID, c.AuthorID, c.Mutual, c.Reason, c.CreatedAt)
+}
+
+// PullRequest загружает PR. Репозиторий PR должен быть в базе или в выгрузке.
+func (i *SnapshotImport) PullRequest(p model.SnapshotPullRequest) (model.ImportOutcome, error) {
+	labels := pq.StringArray(p.Labels)
+	if labels == nil {
+		labels = pq.StringArray{}
+	}
+	return i.insert("pull_requests", []string{
+		"id", "external_id", "pull_request_name", "author_id", "repository_id", "required_reviewers",
+		"lines_added", "lines_removed", "files_changed", "high_risk", "priority", "review_due_at", "labels",
+		"status", "created_at", "merged_at",
+	}, []string{"id"}, p.ID, p.ExternalID, p.Title, p.AuthorID, p.RepositoryID, p.RequiredReviewers,
+		p.LinesAdded, p.LinesRemoved, p.FilesChanged, p.HighRisk, p.Priority, p.ReviewDueAt, labels,
+		p.Status, p.CreatedAt, p.MergedAt)
+}
+
+// ReviewTeam загружает команду, из которой PR набирает ревьюверов.
+func (i *SnapshotImport) ReviewTeam(t model.SnapshotReviewTeam) (model.ImportOutcome, error) {
+	return i.insert("pr_review_teams", []string{"pr_id", "team_id", "approval_required"},
+		[]string{"pr_id", "team_id"}, t.PRID, t.TeamID, t.ApprovalRequired)
+}
+
+// Dependency загружает зависимость PR. Граф зависимостей в выгрузке уже ацикличен,
+// поэтому повторная проверка на цикл не выполняется.
+func (i *SnapshotImport) Dependency(d model.SnapshotDependency) (model.ImportOutcome, error) {
+	return i.insert("pr_dependencies", []string{"pr_id", "depends_on_id", "created_at"},
+		[]string{"pr_id", "depends_on_id"}, d.PRID, d.DependsOnID, d.CreatedAt)
+}
+
+// ExcludedReviewer загружает пользователя, исключенного из ревьюверов PR.
+func (i *SnapshotImport) ExcludedReviewer(e model.SnapshotExcludedReviewer) (model.ImportOutcome, error) {
+	return i.insert("pr_excluded_reviewers", []string{"pr_id", "user_id"},
+		[]string{"pr_id", "user_id"}, e.PRID, e.UserID)
+}
+
+// Assignment загружает назначение ревьювера.
+func (i *SnapshotImport) Assignment(a model.SnapshotAssignment) (model.ImportOutcome, error) {
+	return i.insert("pr_reviewers", []string{"pr_id", "reviewer_id", "role", "decision", "assigned_at", "decided_at"},
+		[]string{"pr_id", "reviewer_id"}, a.PRID, a.ReviewerID, a.Role, a.Decision, a.AssignedAt, a.DecidedAt)
+}
+
+// Revision загружает ревизию PR.
+func (i *SnapshotImport) Revision(rev model.SnapshotRevision) (model.ImportOutcome, error) {
+	return i.insert("pr_revisions", []string{"id", "pr_id", "number", "commit_sha", "approvals_reset", "created_at"},
+		[]string{"id"}, rev.ID, rev.PRID, rev.Number, rev.CommitSHA, rev.ApprovalsReset, rev.CreatedAt)
+}
+
+// ReviewerChange загружает запись истории замен ревьюверов PR.
+func (i *SnapshotImport) ReviewerChange(c model.SnapshotReviewerChange) (model.ImportOutcome, error) {
+	return i.insert("pr_reviewer_changes", []string{
+		"id", "pr_id", "old_reviewer_id", "new_reviewer_id", "kind", "reason", "created_at",
+	}, []string{"id"}, c.ID, c.PRID, c.OldReviewerID, c.NewReviewerID, c.Kind, c.Reason, c.CreatedAt)
+}
+
+// Event загружает событие PR.
+func (i *SnapshotImport) Event(e model.SnapshotEvent) (model.ImportOutcome, error) {
+	payload := []byte(e.Payload)
+	if len(payload) == 0 {
+		payload = []byte(`{}`)
+	}
+	return i.insert("pr_events", []string{"id", "pr_id", "event_type", "payload", "created_at"},
+		[]string{"id"}, e.ID, e.PRID, e.Type, payload, e.CreatedAt)
+}
+
+// insert вставляет строку с учетом политики конфликтов. key — первые колонки columns,
+// образующие первичный или уникальный ключ. При overwrite строка с тем же ключом перезаписывается;
+// при skip и fail конфликт по любому уникальному ограничению пропускает строку или возвращает ErrSnapshotConflict.
+func (i *SnapshotImport) insert(table string, columns, key []string, values ...interface{}) (model.ImportOutcome, error) {
+	placeholders := make([]string, len(columns))
+	for n := range columns {
+		placeholders[n] = fmt.Sprintf("$%d", n+1)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+
+	if i.policy == model.ImportOverwrite {
+		var updates []string
+		for _, column := range columns[len(key):] {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+		}
+		if len(updates) == 0 {
+			// У строки нет колонок кроме ключа: пустое обновление нужно только для RETURNING
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", key[0], key[0]))
+		}
+		// xmax = 0 только у только что вставленной строки, у обновленной он равен id транзакции
+		query += fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s RETURNING (xmax = 0)",
+			strings.Join(key, ", "), strings.Join(updates, ", "))
+		var inserted bool
+		if err := i.tx.QueryRow(query, values...).Scan(&inserted); err != nil {
+			return 0, err
+		}
+		if inserted {
+			return model.ImportInserted, nil
+		}
+		return model.ImportUpdated, nil
+	}
+
+	result, err := i.tx.Exec(query+" ON CONFLICT DO NOTHING", values...)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected > 0 {
+		return model.ImportInserted, nil
+	}
+	if i.policy == model.ImportFail {
+		return 0, ErrSnapshotConflict
+	}
+	return model.ImportSkipped, nil
+}
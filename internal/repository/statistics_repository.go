@@ -19,10 +19,14 @@ func NewStatisticsRepository(db *sql.DB) *StatisticsRepository {
 func (r *StatisticsRepository) GetReviewStats() (*model.ReviewStats, error) {
 	stats := &model.ReviewStats{}
 
-	// Общее количество назначений
+	// Общее количество назначений: обязательные, опциональные и теневые ревьюверы считаются отдельно
 	err := r.DB.QueryRow(`
-		SELECT COUNT(*) FROM pr_reviewers
-	`).Scan(&stats.TotalAssignments)
+		SELECT
+			COUNT(*) FILTER (WHERE role = 'REQUIRED'),
+			COUNT(*) FILTER (WHERE role = 'OPTIONAL'),
+			COUNT(*) FILTER (WHERE role = 'SHADOW')
+		FROM pr_reviewers
+	`).Scan(&stats.TotalAssignments, &stats.OptionalAssignments, &stats.ShadowAssignments)
 	if err != nil {
 		return nil, err
 	}
@@ -32,7 +36,13 @@ func (r *StatisticsRepository) GetReviewStats() (*model.ReviewStats, error) {
 		SELECT 
 			u.id,
 			u.username,
-			COUNT(pr.reviewer_id) as assignments
+			COUNT(pr.reviewer_id) FILTER (WHERE pr.role = 'REQUIRED') as assignments,
+			COUNT(pr.reviewer_id) FILTER (WHERE pr.role = 'OPTIONAL') as optional_assignments,
+			COUNT(pr.reviewer_id) FILTER (WHERE pr.role = 'SHADOW') as shadow_assignments,
+			(
+				SELECT COUNT(*) FROM pr_reviewer_changes c
+				WHERE c.old_reviewer_id = u.id AND c.kind = 'DECLINE'
+			) as declines
 		FROM users u
 		LEFT JOIN pr_reviewers pr ON pr.reviewer_id = u.id
 		GROUP BY u.id, u.username
@@ -49,7 +59,7 @@ func (r *StatisticsRepository) GetReviewStats() (*model.ReviewStats, error) {
 	for rows.Next() {
 		var userStat model.UserAssignmentStats
 		var userID uuid.UUID
-		err = rows.Scan(&userID, &userStat.Username, &userStat.Assignments)
+		err = rows.Scan(&userID, &userStat.Username, &userStat.Assignments, &userStat.Optional, &userStat.Shadow, &userStat.Declines)
 		if err != nil {
 			return nil, err
 		}
@@ -62,7 +72,8 @@ func (r *StatisticsRepository) GetReviewStats() (*model.ReviewStats, error) {
 		SELECT 
 			pr.id,
 			pr.pull_request_name,
-			COUNT(prr.reviewer_id) as reviewers_count,
+			COUNT(prr.reviewer_id) FILTER (WHERE prr.role = 'REQUIRED') as reviewers_count,
+			COUNT(prr.reviewer_id) FILTER (WHERE prr.role = 'OPTIONAL') as optional_count,
 			pr.status::text
 		FROM pull_requests pr
 		LEFT JOIN pr_reviewers prr ON prr.pr_id = pr.id
@@ -80,7 +91,7 @@ func (r *StatisticsRepository) GetReviewStats() (*model.ReviewStats, error) {
 	for rows.Next() {
 		var prStat model.PRAssignmentStats
 		var prID uuid.UUID
-		err = rows.Scan(&prID, &prStat.PRTitle, &prStat.ReviewersCount, &prStat.Status)
+		err = rows.Scan(&prID, &prStat.PRTitle, &prStat.ReviewersCount, &prStat.OptionalCount, &prStat.Status)
 		if err != nil {
 			return nil, err
 		}
@@ -100,6 +111,17 @@ func (r *StatisticsRepository) GetReviewStats() (*model.ReviewStats, error) {
 		return nil, err
 	}
 
+	// Замены ревьюверов: переназначения администратором и самоотводы считаются отдельно
+	err = r.DB.QueryRow(`
+		SELECT
+			COUNT(*) FILTER (WHERE kind = 'REASSIGN') as reassignments,
+			COUNT(*) FILTER (WHERE kind = 'DECLINE') as declines
+		FROM pr_reviewer_changes
+	`).Scan(&stats.Reassignments, &stats.Declines)
+	if err != nil {
+		return nil, err
+	}
+
 	// Среднее количество ревьюверов на PR
 	if stats.TotalPRs > 0 {
 		err = r.DB.QueryRow(`
@@ -107,7 +129,7 @@ func (r *StatisticsRepository) GetReviewStats() (*model.ReviewStats, error) {
 			FROM (
 				SELECT pr.id, COUNT(prr.reviewer_id) as reviewer_count
 				FROM pull_requests pr
-				LEFT JOIN pr_reviewers prr ON prr.pr_id = pr.id
+				LEFT JOIN pr_reviewers prr ON prr.pr_id = pr.id AND prr.role = 'REQUIRED'
 				GROUP BY pr.id
 			) as pr_reviewer_counts
 		`).Scan(&stats.AverageReviewersPerPR)
@@ -116,5 +138,39 @@ func (r *StatisticsRepository) GetReviewStats() (*model.ReviewStats, error) {
 		}
 	}
 
+	// Время до мержа в зависимости от размера PR: S < 50 строк, M 50–499, L 500+
+	sizeRows, err := r.DB.Query(`
+		SELECT size, COUNT(*), COALESCE(AVG(EXTRACT(EPOCH FROM merged_at - created_at) / 3600), 0)::float
+		FROM (
+			SELECT
+				CASE
+					WHEN lines_added + lines_removed < 50 THEN 'S'
+					WHEN lines_added + lines_removed < 500 THEN 'M'
+					ELSE 'L'
+				END as size,
+				created_at, merged_at
+			FROM pull_requests
+			WHERE status = 'MERGED' AND merged_at IS NOT NULL
+		) as merged_sizes
+		GROUP BY size
+		ORDER BY size DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer sizeRows.Close()
+
+	stats.MergeTimeBySize = make([]model.SizeMergeStats, 0)
+	for sizeRows.Next() {
+		var sizeStat model.SizeMergeStats
+		if err := sizeRows.Scan(&sizeStat.Size, &sizeStat.MergedPRs, &sizeStat.AverageMergeHours); err != nil {
+			return nil, err
+		}
+		stats.MergeTimeBySize = append(stats.MergeTimeBySize, sizeStat)
+	}
+	if err := sizeRows.Err(); err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
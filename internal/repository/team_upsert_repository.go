@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"avito-assignment/internal/model"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ApplyUpsert применяет план upsert команды в одной транзакции: создает команду и новых
+// пользователей, обновляет и переводит существующих, снимает с команды выбывших
+// и заменяет их на открытых ревью. Версия существующей команды растет. Если команда, кто-то
+// из существующих пользователей или ревьюверы PR изменились после построения плана,
+// возвращает ErrStaleVersion и ничего не меняет.
+func (r *TeamRepository) ApplyUpsert(plan *model.TeamUpsertPlan) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = tx.Rollback()
+		if err != nil {
+			return
+		}
+	}()
+
+	now := time.Now()
+	if plan.CreateTeam {
+		err = tx.QueryRow(`
+			INSERT INTO teams (id, name, created_at)
+			VALUES ($1, $2, $3)
+			RETURNING version
+		`, plan.Team.ID, plan.Team.Name, now).Scan(&plan.Team.Version)
+		if err != nil {
+			return err
+		}
+	} else if err = bumpTeamVersion(tx, plan.Team.ID, plan.Team.Version); err != nil {
+		return err
+	}
+
+	for _, u := range plan.CreateUsers {
+		_, err = tx.Exec(`
+			INSERT INTO users (id, username, team_id, is_active, is_senior, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, u.ID, u.Username, u.TeamID, u.IsActive, u.IsSenior, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, u := range plan.UpdateUsers {
+		err = execVersioned(tx, `
+			UPDATE users
+			SET username = $1, team_id = $2, is_active = $3, is_senior = $4
+			WHERE id = $5 AND version = $6
+		`, u.Username, u.TeamID, u.IsActive, u.IsSenior, u.ID, u.Version)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, u := range plan.RemoveUsers {
+		err = execVersioned(tx, `
+			UPDATE users
+			SET team_id = NULL
+			WHERE id = $1 AND version = $2
+		`, u.ID, u.Version)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = replaceReviewers(tx, plan.Offboarding, model.ReviewerOffboarded, "offboarding")
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrStaleVersion
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ErrStaleVersion возвращается, когда версия строки в базе не совпадает с ожидаемой:
+// строку успели изменить после того, как клиент ее прочитал.
+var ErrStaleVersion = errors.New("row version mismatch")
+
+// AnyVersion отключает проверку версии строки при изменении.
+const AnyVersion int64 = 0
+
+// bumpPRVersion увеличивает версию PR, когда меняются связанные с ним строки (например, ревьюверы),
+// а сама строка PR — нет.
+func bumpPRVersion(tx *sql.Tx, prID uuid.UUID) error {
+	_, err := tx.Exec(`UPDATE pull_requests SET version = version + 1 WHERE id = $1`, prID)
+	return err
+}
+
+// bumpTeamVersion увеличивает версию команды, когда меняются связанные с ней строки (состав,
+// теневые ревьюверы, уровни ревьюверов). Если версия не равна expectedVersion
+// (AnyVersion — без проверки) или команды нет, возвращает ErrStaleVersion.
+func bumpTeamVersion(tx *sql.Tx, teamID uuid.UUID, expectedVersion int64) error {
+	return execVersioned(tx, `
+		UPDATE teams
+		SET version = version + 1
+		WHERE id = $1 AND ($2 = 0 OR version = $2)
+	`, teamID, expectedVersion)
+}
+
+// execVersioned выполняет UPDATE с условием на версию строки и возвращает ErrStaleVersion,
+// если строка не обновилась.
+func execVersioned(db execer, query string, args ...interface{}) error {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrStaleVersion
+	}
+	return nil
+}
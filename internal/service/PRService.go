@@ -3,76 +3,321 @@ package service
 import (
 	"avito-assignment/internal/model"
 	"avito-assignment/internal/repository"
+	"database/sql"
 	"errors"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // PRService реализует бизнес-логику для работы с Pull Requests.
 type PRService struct {
-	prRepo   *repository.PRRepository
-	userRepo *repository.UserRepository
-	teamRepo *repository.TeamRepository
+	prRepo    *repository.PRRepository
+	userRepo  *repository.UserRepository
+	teamRepo  *repository.TeamRepository
+	eventRepo *repository.EventRepository
+	repoRepo  *repository.RepositoryRepository
+	conflicts *repository.ConflictRepository
+
+	// maxOpenReviews — максимальное число открытых ревью у ревьювера (0 — без ограничения)
+	maxOpenReviews int
+}
+
+// CreatePRResult описывает результат создания PR.
+type CreatePRResult struct {
+	PR *model.PullRequest
+	// Created равен false, если PR с таким external_id уже существовал
+	Created           bool
+	RejectedReviewers []model.RejectedReviewer
+	Explanation       *model.AssignmentExplanation
 }
 
-func NewPRService(prRepo *repository.PRRepository, userRepo *repository.UserRepository, teamRepo *repository.TeamRepository) *PRService {
+func NewPRService(
+	prRepo *repository.PRRepository,
+	userRepo *repository.UserRepository,
+	teamRepo *repository.TeamRepository,
+	eventRepo *repository.EventRepository,
+	repoRepo *repository.RepositoryRepository,
+	conflicts *repository.ConflictRepository,
+	maxOpenReviews int,
+) *PRService {
 	return &PRService{
-		prRepo:   prRepo,
-		userRepo: userRepo,
-		teamRepo: teamRepo,
+		prRepo:         prRepo,
+		userRepo:       userRepo,
+		teamRepo:       teamRepo,
+		eventRepo:      eventRepo,
+		repoRepo:       repoRepo,
+		conflicts:      conflicts,
+		maxOpenReviews: maxOpenReviews,
 	}
 }
 
 // CreatePR создает новый Pull Request и автоматически назначает ревьюверов.
-func (s *PRService) CreatePR(pr *model.PullRequest) (*model.PullRequest, error) {
+// PR всегда относится к репозиторию: количество ревьюверов и стратегия берутся
+// из его настроек, а кандидаты — из команд-владельцев, когда команда
+// автора репозиторием не владеет. Кросс-командный PR набирает ревьюверов из своих
+// целевых команд — минимум по одному от каждой.
+//
+// Запрошенные автором ревьюверы назначаются всегда, если они подходят (иначе попадают
+// в RejectedReviewers с причиной), исключенные автором не назначаются никогда, а стратегия
+// заполняет только оставшиеся места.
+//
+// Создание идемпотентно по external_id: если PR с таким внешним идентификатором
+// уже существует, он возвращается без изменений, а Created равен false.
+func (s *PRService) CreatePR(pr *model.PullRequest, prefs model.ReviewerPreferences) (*CreatePRResult, error) {
+	if pr.ExternalID != nil {
+		existing, errGetting := s.prRepo.GetByExternalID(*pr.ExternalID)
+		if errGetting == nil {
+			return &CreatePRResult{PR: existing}, nil
+		}
+		if !errors.Is(errGetting, sql.ErrNoRows) {
+			return nil, errGetting
+		}
+	}
+
+	if pr.Priority == "" {
+		pr.Priority = model.PriorityNormal
+	}
+	if _, ok := priorityWeights[pr.Priority]; !ok {
+		return nil, ErrInvalidPriority
+	}
+	pr.Labels = normalizeLabels(pr.Labels)
+
 	author, err := s.userRepo.GetUserByID(pr.AuthorID)
 	if err != nil {
-		return nil, errors.New("Автор/команда не найдены")
+		return nil, ErrAuthorNotFound
+	}
+
+	if pr.RepositoryID == uuid.Nil {
+		return nil, ErrRepositoryIDRequired
+	}
+	repo, err := s.repoRepo.GetByID(pr.RepositoryID)
+	if err != nil {
+		return nil, ErrRepositoryNotFound
+	}
+	count := repo.RequiredReviewers
+	strategy := repo.AssignmentStrategy
+	teamIDs := reviewTeamsForRepository(repo, author.TeamID)
+
+	// Целевые команды кросс-командного PR заменяют команды ревью по умолчанию
+	pr.TargetTeamIDs = uniqueIDs(pr.TargetTeamIDs)
+	if len(pr.TargetTeamIDs) > 0 {
+		for _, teamID := range pr.TargetTeamIDs {
+			if _, errTeam := s.teamRepo.GetByID(teamID); errTeam != nil {
+				return nil, ErrTeamNotFound
+			}
+		}
+		teamIDs = pr.TargetTeamIDs
+	}
+
+	conflictRules, err := s.conflicts.GetForAuthor(pr.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	conflicting := make([]uuid.UUID, 0, len(conflictRules))
+	for _, rule := range conflictRules {
+		conflicting = append(conflicting, rule.ReviewerID)
+	}
+
+	excluded := uniqueIDs(prefs.Excluded)
+	requested, rejected, err := s.resolveRequestedReviewers(pr.AuthorID, prefs.Requested, excluded, conflicting)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation, err := s.explainConflicts(conflictRules, teamIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	excludeIDs := []uuid.UUID{pr.AuthorID}
+	excludeIDs = append(excludeIDs, requested...)
+	excludeIDs = append(excludeIDs, excluded...)
+	excludeIDs = append(excludeIDs, conflicting...)
+	candidates, err := s.userRepo.GetActiveUsersByTeams(teamIDs, pr.AuthorID, excludeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tier, err := s.reviewerTierFor(teamIDs, pr.DiffStats)
+	if err != nil {
+		return nil, err
+	}
+	seniorsNeeded := 0
+	if tier != nil {
+		count = tier.Reviewers
+		seniorsNeeded = tier.SeniorReviewers
+	}
+	// В кросс-командном PR нужен хотя бы один ревьювер от каждой целевой команды
+	if len(pr.TargetTeamIDs) > count {
+		count = len(pr.TargetTeamIDs)
+	}
+
+	// Запрошенные автором ревьюверы засчитываются в требование уровня и покрытие команд
+	covered := make(map[uuid.UUID]bool, len(requested))
+	for _, id := range requested {
+		u, errUser := s.userRepo.GetUserByID(id)
+		if errUser != nil {
+			continue
+		}
+		if u.IsSenior {
+			seniorsNeeded--
+		}
+		covered[u.TeamID] = true
+	}
+
+	remaining := count - len(requested)
+	if remaining < 0 {
+		remaining = 0
+	}
+	reviewers := requested
+	for _, teamID := range pr.TargetTeamIDs {
+		if remaining == 0 || covered[teamID] {
+			continue
+		}
+		teamCandidates := make([]model.User, 0, len(candidates))
+		for _, c := range candidates {
+			if c.TeamID == teamID {
+				teamCandidates = append(teamCandidates, c)
+			}
+		}
+		pickedFromTeam, errTeam := s.selectReviewers(strategy, teamCandidates, 1)
+		if errTeam != nil {
+			return nil, errTeam
+		}
+		for _, c := range teamCandidates {
+			if containsID(pickedFromTeam, c.ID) && c.IsSenior {
+				seniorsNeeded--
+			}
+		}
+		reviewers = append(reviewers, pickedFromTeam...)
+		remaining -= len(pickedFromTeam)
+		candidates = withoutUsers(candidates, pickedFromTeam)
+	}
+
+	if seniorsNeeded > 0 && remaining > 0 {
+		seniors := make([]model.User, 0, len(candidates))
+		for _, c := range candidates {
+			if c.IsSenior {
+				seniors = append(seniors, c)
+			}
+		}
+		pickedSeniors, errSeniors := s.selectReviewers(strategy, seniors, min(seniorsNeeded, remaining))
+		if errSeniors != nil {
+			return nil, errSeniors
+		}
+		reviewers = append(reviewers, pickedSeniors...)
+		remaining -= len(pickedSeniors)
+		candidates = withoutUsers(candidates, pickedSeniors)
+	}
+	picked, err := s.selectReviewers(strategy, candidates, remaining)
+	if err != nil {
+		return nil, err
 	}
+	reviewers = append(reviewers, picked...)
 
-	candidates, err := s.userRepo.GetActiveUsersByTeam(author.TeamID, pr.AuthorID)
+	shadow, shadowTeamID, err := s.pickShadowReviewer(teamIDs, append(excludeIDs, reviewers...))
 	if err != nil {
 		return nil, err
 	}
+	if shadow != uuid.Nil {
+		pr.ShadowReviewers = []uuid.UUID{shadow}
+	}
 
-	reviewers := s.selectRandomReviewers(candidates, 2)
+	pr.DependsOn = uniqueIDs(pr.DependsOn)
+	for _, depID := range pr.DependsOn {
+		if _, err = s.prRepo.GetByID(depID); err != nil {
+			return nil, ErrDependencyNotFound
+		}
+	}
 
 	pr.ID = uuid.New()
 	pr.Status = model.OPEN
 	pr.CreatedAt = time.Now()
 	pr.Reviewers = reviewers
+	pr.RequiredReviewers = count
+	pr.ReviewTeamIDs = make([]uuid.UUID, 0, len(teamIDs))
+	for _, teamID := range teamIDs {
+		if teamID != uuid.Nil {
+			pr.ReviewTeamIDs = append(pr.ReviewTeamIDs, teamID)
+		}
+	}
 
-	err = s.prRepo.Create(pr, reviewers)
+	err = s.prRepo.Create(pr, reviewers, excluded, shadowTeamID)
 	if err != nil {
+		// Параллельный запрос с тем же external_id успел создать PR первым
+		if pr.ExternalID != nil && isUniqueViolation(err) {
+			existing, errGetting := s.prRepo.GetByExternalID(*pr.ExternalID)
+			if errGetting == nil {
+				return &CreatePRResult{PR: existing}, nil
+			}
+		}
 		return nil, err
 	}
 
-	return pr, nil
+	createdPR, err := s.prRepo.GetByID(pr.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &CreatePRResult{
+		PR:                createdPR,
+		Created:           true,
+		RejectedReviewers: rejected,
+		Explanation:       explanation,
+	}, nil
 }
 
 // GetPRByID возвращает Pull Request по его идентификатору.
 func (s *PRService) GetPRByID(id uuid.UUID) (*model.PullRequest, error) {
 	pr, err := s.prRepo.GetByID(id)
 	if err != nil {
-		return nil, errors.New("pull request not found")
+		return nil, ErrPRNotFound
 	}
 	return pr, nil
 }
 
-// ReassignReviewer переназначает одного ревьювера на другого.
+// ReassignReviewer переназначает одного ревьювера на другого. Если версия PR не равна
+// expectedVersion (repository.AnyVersion — без проверки), возвращает ErrVersionMismatch.
 func (s *PRService) ReassignReviewer(
 	prID uuid.UUID,
 	oldReviewerID uuid.UUID,
+	expectedVersion int64,
+) (*model.PullRequest, uuid.UUID, error) {
+	return s.replaceReviewer(prID, oldReviewerID, model.ReviewerReassigned, "", expectedVersion)
+}
+
+// DeclineReview снимает ревьювера с PR по его собственной просьбе и подбирает замену
+// обычной стратегией. Отказавшийся ревьювер больше никогда не назначается на этот PR.
+// Если замены нет, ревьювер все равно снимается, а вместо ID замены возвращается uuid.Nil.
+func (s *PRService) DeclineReview(
+	prID uuid.UUID,
+	reviewerID uuid.UUID,
+	reason string,
+) (*model.PullRequest, uuid.UUID, error) {
+	return s.replaceReviewer(prID, reviewerID, model.ReviewerDeclined, reason, repository.AnyVersion)
+}
+
+// replaceReviewer заменяет ревьювера PR и записывает замену в историю.
+func (s *PRService) replaceReviewer(
+	prID uuid.UUID,
+	oldReviewerID uuid.UUID,
+	kind model.ReviewerChangeKind,
+	reason string,
+	expectedVersion int64,
 ) (*model.PullRequest, uuid.UUID, error) {
 	pr, err := s.prRepo.GetByID(prID)
 	if err != nil {
-		return nil, uuid.Nil, errors.New("pull request not found")
+		return nil, uuid.Nil, ErrPRNotFound
+	}
+	if expectedVersion != repository.AnyVersion && pr.Version != expectedVersion {
+		return nil, uuid.Nil, ErrVersionMismatch
 	}
 
 	if pr.Status == model.MERGED {
-		return nil, uuid.Nil, errors.New("cannot reassign reviewers for merged PR")
+		return nil, uuid.Nil, ErrPRMerged
 	}
 
 	found := false
@@ -83,36 +328,26 @@ func (s *PRService) ReassignReviewer(
 		}
 	}
 	if !found {
-		return nil, uuid.Nil, errors.New("reviewer not assigned to this PR")
+		return nil, uuid.Nil, ErrReviewerNotAssigned
 	}
 
 	oldReviewer, err := s.userRepo.GetUserByID(oldReviewerID)
 	if err != nil {
-		return nil, uuid.Nil, errors.New("old reviewer not found")
-	}
-
-	excludeIDs := []uuid.UUID{pr.AuthorID, oldReviewerID}
-	for _, reviewerID := range pr.Reviewers {
-		if reviewerID != oldReviewerID {
-			excludeIDs = append(excludeIDs, reviewerID)
-		}
+		return nil, uuid.Nil, ErrUserNotFound
 	}
 
-	candidates, err := s.userRepo.GetActiveUsersByTeamExcluding(
-		oldReviewer.TeamID,
-		excludeIDs,
-	)
+	newReviewerID, err := s.pickReplacement(pr, oldReviewer.TeamID)
 	if err != nil {
 		return nil, uuid.Nil, err
 	}
-	if len(candidates) == 0 {
-		return nil, uuid.Nil, errors.New("no available reviewers in the team")
+	if newReviewerID == uuid.Nil && kind == model.ReviewerReassigned {
+		return nil, uuid.Nil, ErrNoCandidate
 	}
 
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-	newReviewerID := candidates[rng.Intn(len(candidates))].ID
-
-	err = s.prRepo.ReassignReviewer(prID, oldReviewerID, newReviewerID)
+	err = s.prRepo.ReassignReviewer(prID, oldReviewerID, newReviewerID, kind, reason, expectedVersion)
+	if errors.Is(err, repository.ErrStaleVersion) {
+		return nil, uuid.Nil, ErrVersionMismatch
+	}
 	if err != nil {
 		return nil, uuid.Nil, err
 	}
@@ -125,24 +360,373 @@ func (s *PRService) ReassignReviewer(
 	return updated, newReviewerID, nil
 }
 
-// MergePR переводит Pull Request в статус MERGED.
-func (s *PRService) MergePR(prID uuid.UUID) (*model.PullRequest, error) {
-	_, err := s.prRepo.GetByID(prID)
+// pickReplacement выбирает замену ревьюверу из команды teamID по стратегии PR,
+// исключая автора, текущих ревьюверов (в любой роли), исключенных из ревью этого PR (самоотвод или запрет автора)
+// и дополнительно переданных пользователей.
+// Если подходящих кандидатов нет, возвращает uuid.Nil.
+func (s *PRService) pickReplacement(pr *model.PullRequest, teamID uuid.UUID, extraExclude ...uuid.UUID) (uuid.UUID, error) {
+	excluded, err := s.prRepo.GetExcludedReviewers(pr.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	excludeIDs := []uuid.UUID{pr.AuthorID}
+	excludeIDs = append(excludeIDs, pr.Reviewers...)
+	excludeIDs = append(excludeIDs, pr.OptionalReviewers...)
+	excludeIDs = append(excludeIDs, pr.ShadowReviewers...)
+	excludeIDs = append(excludeIDs, excluded...)
+	excludeIDs = append(excludeIDs, extraExclude...)
+
+	candidates, err := s.userRepo.GetActiveUsersByTeamExcluding(teamID, pr.AuthorID, excludeIDs)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	strategy, err := s.strategyForPR(pr)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	picked, err := s.selectReviewers(strategy, candidates, 1)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if len(picked) == 0 {
+		return uuid.Nil, nil
+	}
+	return picked[0], nil
+}
+
+// strategyForPR возвращает стратегию назначения ревьюверов для PR.
+func (s *PRService) strategyForPR(pr *model.PullRequest) (model.AssignmentStrategy, error) {
+	repo, err := s.repoRepo.GetByID(pr.RepositoryID)
+	if err != nil {
+		return "", err
+	}
+	return repo.AssignmentStrategy, nil
+}
+
+// MergePR переводит Pull Request в статус MERGED. Зависимые PR, у которых не осталось
+// несмерженных зависимостей, получают событие PR_UNBLOCKED в той же транзакции.
+// Мерж запрещен, пока хотя бы одна зависимость PR не смержена, а кросс-командный
+// PR — пока его не одобрил хотя бы один обязательный ревьювер от каждой целевой команды.
+// Уже смерженный PR возвращается без проверки версии, чтобы повторный мерж оставался идемпотентным;
+// у открытого PR версия должна быть равна expectedVersion (repository.AnyVersion — без проверки).
+func (s *PRService) MergePR(prID uuid.UUID, expectedVersion int64) (*model.PullRequest, error) {
+	pr, err := s.prRepo.GetByID(prID)
+	if err != nil {
+		return nil, ErrPRNotFound
+	}
+
+	if pr.Status == model.MERGED {
+		return pr, nil
+	}
+	if expectedVersion != repository.AnyVersion && pr.Version != expectedVersion {
+		return nil, ErrVersionMismatch
+	}
+
+	err = s.prRepo.Merge(prID, expectedVersion)
+	if errors.Is(err, repository.ErrStaleVersion) {
+		return nil, ErrVersionMismatch
+	}
+	var blocked *repository.DependenciesBlockedError
+	if errors.As(err, &blocked) {
+		return nil, ErrPRBlocked.WithDetails(map[string][]uuid.UUID{"blocking_pr_ids": blocked.PRIDs})
+	}
+	var unapproved *repository.ApprovalsMissingError
+	if errors.As(err, &unapproved) {
+		return nil, ErrTeamApprovalsMissing.WithDetails(map[string][]uuid.UUID{"missing_team_ids": unapproved.TeamIDs})
+	}
 	if err != nil {
-		return nil, errors.New("pull request not found")
+		return nil, err
 	}
 
-	err = s.prRepo.Merge(prID)
+	return s.prRepo.GetByID(prID)
+}
+
+// AddDependencies добавляет PR зависимости от других PR с проверкой на циклы.
+func (s *PRService) AddDependencies(prID uuid.UUID, dependsOn []uuid.UUID) (*model.PullRequest, error) {
+	pr, err := s.prRepo.GetByID(prID)
 	if err != nil {
+		return nil, ErrPRNotFound
+	}
+	if pr.Status == model.MERGED {
+		return nil, ErrPRMerged
+	}
+
+	dependsOn = uniqueIDs(dependsOn)
+	for _, depID := range dependsOn {
+		if depID == prID {
+			return nil, ErrDependencyCycle
+		}
+		if _, err = s.prRepo.GetByID(depID); err != nil {
+			return nil, ErrDependencyNotFound
+		}
+	}
+
+	err = s.prRepo.AddDependencies(prID, dependsOn)
+	switch {
+	case errors.Is(err, repository.ErrDependencyCycle):
+		return nil, ErrDependencyCycle
+	case isForeignKeyViolation(err):
+		// Зависимость удалили после проверки
+		return nil, ErrDependencyNotFound
+	case err != nil:
 		return nil, err
 	}
 
 	return s.prRepo.GetByID(prID)
 }
 
-// GetAllPRs возвращает все Pull Requests из системы.
-func (s *PRService) GetAllPRs() ([]model.PullRequest, error) {
-	return s.prRepo.GetAll()
+// AddOptionalReviewers добавляет к PR опциональных ревьюверов (наблюдателей).
+// Они видят PR, но не учитываются в политике мержа и в количестве требуемых ревьюверов.
+func (s *PRService) AddOptionalReviewers(prID uuid.UUID, userIDs []uuid.UUID) (*model.PullRequest, error) {
+	pr, err := s.prRepo.GetByID(prID)
+	if err != nil {
+		return nil, ErrPRNotFound
+	}
+	if pr.Status == model.MERGED {
+		return nil, ErrPRMerged
+	}
+
+	userIDs = uniqueIDs(userIDs)
+	for _, userID := range userIDs {
+		if userID == pr.AuthorID {
+			return nil, ErrAuthorCannotReview
+		}
+		user, err := s.userRepo.GetUserByID(userID)
+		if err != nil {
+			return nil, ErrUserNotFound
+		}
+		if !user.IsActive {
+			return nil, ErrUserInactive
+		}
+	}
+
+	if err = s.prRepo.AddOptionalReviewers(prID, userIDs); err != nil {
+		return nil, err
+	}
+	return s.prRepo.GetByID(prID)
+}
+
+// GetPREvents возвращает события (уведомления) по PR.
+func (s *PRService) GetPREvents(prID uuid.UUID) ([]model.PREvent, error) {
+	if _, err := s.prRepo.GetByID(prID); err != nil {
+		return nil, ErrPRNotFound
+	}
+	return s.eventRepo.GetByPR(prID)
+}
+
+// resolveRequestedReviewers проверяет запрошенных автором ревьюверов и возвращает
+// подходящих, а для остальных — причину отказа.
+func (s *PRService) resolveRequestedReviewers(
+	authorID uuid.UUID,
+	requested []uuid.UUID,
+	excluded []uuid.UUID,
+	conflicting []uuid.UUID,
+) ([]uuid.UUID, []model.RejectedReviewer, error) {
+	requested = uniqueIDs(requested)
+	accepted := make([]uuid.UUID, 0, len(requested))
+	rejected := make([]model.RejectedReviewer, 0)
+	if len(requested) == 0 {
+		return accepted, rejected, nil
+	}
+
+	load := map[uuid.UUID]int{}
+	if s.maxOpenReviews > 0 {
+		var err error
+		load, err = s.userRepo.GetOpenReviewCounts(requested)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, userID := range requested {
+		var reason model.RejectionReason
+		user, err := s.userRepo.GetUserByID(userID)
+		switch {
+		case userID == authorID:
+			reason = model.RejectedAuthor
+		case errors.Is(err, sql.ErrNoRows):
+			reason = model.RejectedAbsent
+		case err != nil:
+			return nil, nil, err
+		case !user.IsActive:
+			reason = model.RejectedInactive
+		case containsID(excluded, userID):
+			reason = model.RejectedExcluded
+		case containsID(conflicting, userID):
+			reason = model.RejectedConflict
+		case s.maxOpenReviews > 0 && load[userID] >= s.maxOpenReviews:
+			reason = model.RejectedAtCapacity
+		}
+
+		if reason != "" {
+			rejected = append(rejected, model.RejectedReviewer{UserID: userID, Reason: reason})
+			continue
+		}
+		accepted = append(accepted, userID)
+	}
+	return accepted, rejected, nil
+}
+
+// explainConflicts описывает, кого из участников команд ревью исключили правила
+// конфликта интересов с автором.
+func (s *PRService) explainConflicts(rules []model.ConflictRule, teamIDs []uuid.UUID) (*model.AssignmentExplanation, error) {
+	explanation := &model.AssignmentExplanation{
+		ConflictExclusions: make([]model.ConflictExclusion, 0),
+	}
+	for _, rule := range rules {
+		user, err := s.userRepo.GetUserByID(rule.ReviewerID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return nil, err
+		}
+		if !user.IsActive || !containsID(teamIDs, user.TeamID) {
+			continue
+		}
+		explanation.ConflictExclusions = append(explanation.ConflictExclusions, model.ConflictExclusion{
+			UserID:         user.ID,
+			Username:       user.Username,
+			ConflictRuleID: rule.ID,
+			Reason:         rule.Reason,
+		})
+	}
+	return explanation, nil
+}
+
+// filterAtCapacity убирает кандидатов, у которых открытых ревью не меньше лимита.
+func (s *PRService) filterAtCapacity(candidates []model.User) ([]model.User, error) {
+	if s.maxOpenReviews <= 0 || len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.ID)
+	}
+	load, err := s.userRepo.GetOpenReviewCounts(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make([]model.User, 0, len(candidates))
+	for _, c := range candidates {
+		if load[c.ID] < s.maxOpenReviews {
+			available = append(available, c)
+		}
+	}
+	return available, nil
+}
+
+// pickShadowReviewer выбирает теневого ревьювера-джуниора по ротации первой из команд ревью,
+// у которой включено наставничество, и возвращает его вместе с этой командой.
+// Если такой команды или свободного джуниора нет, возвращает uuid.Nil.
+func (s *PRService) pickShadowReviewer(teamIDs, excludeIDs []uuid.UUID) (uuid.UUID, uuid.UUID, error) {
+	for _, teamID := range teamIDs {
+		team, err := s.teamRepo.GetByID(teamID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return uuid.Nil, uuid.Nil, err
+		}
+		if !team.ShadowingEnabled {
+			continue
+		}
+
+		shadowID, err := s.teamRepo.NextShadowReviewer(teamID, excludeIDs)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return uuid.Nil, uuid.Nil, err
+		}
+		return shadowID, teamID, nil
+	}
+	return uuid.Nil, uuid.Nil, nil
+}
+
+// reviewTeamsForRepository возвращает команды, из которых выбираются ревьюверы PR
+// в репозитории: команда автора, если она владеет репозиторием (или владельцы не заданы),
+// иначе — команды-владельцы.
+func reviewTeamsForRepository(repo *model.Repository, authorTeamID uuid.UUID) []uuid.UUID {
+	if len(repo.OwnerTeamIDs) == 0 {
+		return []uuid.UUID{authorTeamID}
+	}
+	for _, teamID := range repo.OwnerTeamIDs {
+		if teamID == authorTeamID {
+			return []uuid.UUID{authorTeamID}
+		}
+	}
+	return repo.OwnerTeamIDs
+}
+
+// reviewerTierFor подбирает уровень числа ревьюверов по размеру и риску PR.
+// Используются уровни первой команды ревью; для рискованных PR приоритет у уровней с high_risk.
+// Возвращает nil, если подходящего уровня нет и действует число ревьюверов репозитория.
+func (s *PRService) reviewerTierFor(teamIDs []uuid.UUID, diff model.DiffStats) (*model.ReviewerTier, error) {
+	if len(teamIDs) == 0 || teamIDs[0] == uuid.Nil {
+		return nil, nil
+	}
+	tiers, err := s.teamRepo.GetReviewerTiers(teamIDs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var best *model.ReviewerTier
+	for i := range tiers {
+		t := &tiers[i]
+		if t.MinLines > diff.TotalLines() || (t.HighRisk && !diff.HighRisk) {
+			continue
+		}
+		if best == nil || (t.HighRisk && !best.HighRisk) ||
+			(t.HighRisk == best.HighRisk && t.MinLines > best.MinLines) {
+			best = t
+		}
+	}
+	return best, nil
+}
+
+// selectReviewers выбирает ревьюверов согласно стратегии назначения.
+// Кандидаты, достигшие лимита открытых ревью, не выбираются.
+func (s *PRService) selectReviewers(strategy model.AssignmentStrategy, candidates []model.User, maxCount int) ([]uuid.UUID, error) {
+	candidates, err := s.filterAtCapacity(candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	if strategy != model.StrategyLeastLoaded || len(candidates) <= maxCount {
+		return s.selectRandomReviewers(candidates, maxCount), nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.ID)
+	}
+	load, err := s.userRepo.GetOpenReviewCounts(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Перемешиваем, чтобы при равной загрузке выбор оставался случайным
+	shuffled := make([]model.User, len(candidates))
+	copy(shuffled, candidates)
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	sort.SliceStable(shuffled, func(i, j int) bool {
+		return load[shuffled[i].ID] < load[shuffled[j].ID]
+	})
+
+	reviewers := make([]uuid.UUID, 0, maxCount)
+	for i := 0; i < maxCount; i++ {
+		reviewers = append(reviewers, shuffled[i].ID)
+	}
+	return reviewers, nil
 }
 
 // selectRandomReviewers выбирает случайных ревьюверов из списка кандидатов.
@@ -171,3 +755,39 @@ func (s *PRService) selectRandomReviewers(candidates []model.User, maxCount int)
 
 	return reviewers
 }
+
+// withoutUsers возвращает кандидатов без уже выбранных пользователей.
+func withoutUsers(candidates []model.User, ids []uuid.UUID) []model.User {
+	rest := make([]model.User, 0, len(candidates))
+	for _, c := range candidates {
+		if !containsID(ids, c.ID) {
+			rest = append(rest, c)
+		}
+	}
+	return rest
+}
+
+// isUniqueViolation проверяет, что ошибка PostgreSQL — нарушение уникальности.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// uniqueIDs убирает повторы, сохраняя порядок.
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	result := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
@@ -3,8 +3,9 @@ package service
 import (
 	"avito-assignment/internal/model"
 	"avito-assignment/internal/repository"
+	"database/sql"
 	"errors"
-	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -21,34 +22,43 @@ func NewTeamService(teamRepo *repository.TeamRepository, userRepo *repository.Us
 	}
 }
 
-// CreateTeam создает команду
+// CreateTeam создает команду вместе с новыми участниками в одной транзакции:
+// если хотя бы одного участника создать нельзя, не создается ничего.
 func (s *TeamService) CreateTeam(team *model.Team) (*model.Team, error) {
 	// Проверяем уникальность имени
 	existing, _ := s.teamRepo.GetByName(team.Name)
 	if existing != nil {
-		return nil, errors.New("team with this name already exists" + team.Name)
+		return nil, ErrTeamExists
 	}
 
 	team.ID = uuid.New()
-	err := s.teamRepo.Create(team)
-	if err != nil {
-		return nil, err
-	}
-
+	plan := &model.TeamUpsertPlan{Team: *team, CreateTeam: true}
+	usernames := make(map[string]bool, len(team.Members))
 	for _, member := range team.Members {
-		userID := uuid.New()
-		user := &model.User{
-			ID:       userID,
+		invalid := ErrInvalidTeamMember.WithDetails(map[string]string{"username": member.Username})
+		if strings.TrimSpace(member.Username) == "" || usernames[member.Username] {
+			return nil, invalid
+		}
+		if _, err := s.userRepo.GetUserByUsername(member.Username); err == nil {
+			return nil, invalid
+		}
+		usernames[member.Username] = true
+
+		plan.CreateUsers = append(plan.CreateUsers, model.User{
+			ID:       uuid.New(),
 			Username: member.Username,
 			TeamID:   team.ID,
 			IsActive: member.IsActive,
-		}
-		err = s.userRepo.CreateUser(user)
-		if err != nil {
-			return nil, fmt.Errorf("invalid user_id: %s", user.ID)
-		}
+			IsSenior: member.IsSenior,
+		})
+	}
+
+	if err := s.applyUpsertPlan(plan, repository.AnyVersion); err != nil {
+		return nil, err
 	}
+	team.Version = plan.Team.Version
 
+	var err error
 	team.Members, err = s.userRepo.GetUsersByTeam(team.ID)
 	if err != nil {
 		return nil, err
@@ -61,7 +71,7 @@ func (s *TeamService) CreateTeam(team *model.Team) (*model.Team, error) {
 func (s *TeamService) GetTeamByID(id uuid.UUID) (*model.Team, error) {
 	team, err := s.teamRepo.GetByID(id)
 	if err != nil {
-		return nil, errors.New("team not found")
+		return nil, ErrTeamNotFound
 	}
 
 	// Загружаем участников
@@ -70,87 +80,236 @@ func (s *TeamService) GetTeamByID(id uuid.UUID) (*model.Team, error) {
 		team.Members = members
 	}
 
+	shadowUsers, err := s.teamRepo.GetShadowUsers(id)
+	if err == nil {
+		team.ShadowUserIDs = shadowUsers
+	}
+
+	tiers, err := s.teamRepo.GetReviewerTiers(id)
+	if err == nil {
+		team.ReviewerTiers = tiers
+	}
+
 	return team, nil
 }
 
-// UpdateTeam обновляет команду
-func (s *TeamService) UpdateTeam(team *model.Team) (*model.Team, error) {
-	// Проверяем существование
-	_, err := s.teamRepo.GetByID(team.ID)
+// UpdateTeam переименовывает команду, если ее версия равна expectedVersion
+// (repository.AnyVersion — без проверки), иначе возвращает ErrVersionMismatch.
+// Настройки команды сохраняются: они меняются через PatchTeam и отдельные эндпоинты.
+func (s *TeamService) UpdateTeam(team *model.Team, expectedVersion int64) (*model.Team, error) {
+	existing, err := s.teamRepo.GetByID(team.ID)
 	if err != nil {
-		return nil, errors.New("team not found")
+		return nil, ErrTeamNotFound
+	}
+	team.ShadowingEnabled = existing.ShadowingEnabled
+	team.ResetApprovalsOnRevision = existing.ResetApprovalsOnRevision
+
+	if err = s.saveTeam(team, expectedVersion); err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// teamPatchFields — поля команды, которые можно менять через PATCH. Участники, теневые ревьюверы
+// и уровни ревьюверов меняются отдельными эндпоинтами.
+var teamPatchFields = map[string]bool{"team_name": true, "shadowing_enabled": true, "reset_approvals_on_revision": true}
+
+// PatchTeam применяет к команде JSON Merge Patch (RFC 7396): меняются только переданные поля,
+// остальные сохраняют текущие значения.
+func (s *TeamService) PatchTeam(id uuid.UUID, patch []byte, expectedVersion int64) (*model.Team, error) {
+	existing, err := s.teamRepo.GetByID(id)
+	if err != nil {
+		return nil, ErrTeamNotFound
+	}
+	if expectedVersion != repository.AnyVersion && existing.Version != expectedVersion {
+		return nil, ErrVersionMismatch
+	}
+
+	team := *existing
+	if err = applyMergePatch(&team, patch, teamPatchFields); err != nil {
+		return nil, err
+	}
+	team.ID = id
+
+	if err = s.saveTeam(&team, expectedVersion); err != nil {
+		return nil, err
+	}
+	return s.GetTeamByID(id)
+}
+
+// saveTeam проверяет название команды и сохраняет ее с проверкой версии.
+func (s *TeamService) saveTeam(team *model.Team, expectedVersion int64) error {
+	if strings.TrimSpace(team.Name) == "" {
+		return ErrTeamNameRequired
 	}
 
 	// Проверяем уникальность имени (если изменилось)
-	existing, errGetting := s.teamRepo.GetByName(team.Name)
-	if errGetting != nil {
-		return nil, errGetting
+	existing, err := s.teamRepo.GetByName(team.Name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
 	}
 	if existing != nil && existing.ID != team.ID {
-		return nil, errors.New("team with this name already exists")
+		return ErrTeamExists
+	}
+
+	err = s.teamRepo.Update(team, expectedVersion)
+	if errors.Is(err, repository.ErrStaleVersion) {
+		return ErrVersionMismatch
 	}
+	return err
+}
 
-	err = s.teamRepo.Update(team)
+// UpdateShadowing настраивает наставничество: включает/выключает теневых ревьюверов
+// и задает джуниоров команды, которые назначаются ими по очереди. Версия команды должна быть
+// равна expectedVersion (repository.AnyVersion — без проверки), иначе возвращает ErrVersionMismatch.
+func (s *TeamService) UpdateShadowing(teamID uuid.UUID, enabled bool, userIDs []uuid.UUID, expectedVersion int64) (*model.Team, error) {
+	if _, err := s.teamRepo.GetByID(teamID); err != nil {
+		return nil, ErrTeamNotFound
+	}
+
+	userIDs = uniqueIDs(userIDs)
+	for _, userID := range userIDs {
+		user, err := s.userRepo.GetUserByID(userID)
+		if err != nil {
+			return nil, ErrUserNotFound
+		}
+		if user.TeamID != teamID {
+			return nil, ErrNotTeamMember
+		}
+	}
+
+	err := s.teamRepo.UpdateShadowing(teamID, enabled, userIDs, expectedVersion)
+	if errors.Is(err, repository.ErrStaleVersion) {
+		return nil, ErrVersionMismatch
+	}
 	if err != nil {
 		return nil, err
 	}
-	return team, nil
+	return s.GetTeamByID(teamID)
+}
+
+// UpdateReviewerTiers задает уровни числа ревьюверов команды в зависимости от размера и риска PR.
+// Версия команды проверяется так же, как в UpdateShadowing.
+func (s *TeamService) UpdateReviewerTiers(teamID uuid.UUID, tiers []model.ReviewerTier, expectedVersion int64) (*model.Team, error) {
+	if _, err := s.teamRepo.GetByID(teamID); err != nil {
+		return nil, ErrTeamNotFound
+	}
+
+	seen := make(map[model.ReviewerTier]bool, len(tiers))
+	for _, t := range tiers {
+		if t.MinLines < 0 || t.Reviewers < 0 || t.SeniorReviewers < 0 || t.SeniorReviewers > t.Reviewers {
+			return nil, ErrInvalidReviewerTier
+		}
+		key := model.ReviewerTier{MinLines: t.MinLines, HighRisk: t.HighRisk}
+		if seen[key] {
+			return nil, ErrInvalidReviewerTier
+		}
+		seen[key] = true
+	}
+
+	err := s.teamRepo.ReplaceReviewerTiers(teamID, tiers, expectedVersion)
+	if errors.Is(err, repository.ErrStaleVersion) {
+		return nil, ErrVersionMismatch
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTeamByID(teamID)
+}
+
+// UpdateReviewPolicy задает, сбрасываются ли одобрения PR команды при новой ревизии.
+// Версия команды проверяется так же, как в UpdateShadowing.
+func (s *TeamService) UpdateReviewPolicy(teamID uuid.UUID, resetApprovals bool, expectedVersion int64) (*model.Team, error) {
+	if _, err := s.teamRepo.GetByID(teamID); err != nil {
+		return nil, ErrTeamNotFound
+	}
+	err := s.teamRepo.UpdateReviewPolicy(teamID, resetApprovals, expectedVersion)
+	if errors.Is(err, repository.ErrStaleVersion) {
+		return nil, ErrVersionMismatch
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTeamByID(teamID)
 }
 
 // DeleteTeam удаляет команду
 func (s *TeamService) DeleteTeam(id uuid.UUID) error {
-	return s.teamRepo.Delete(id)
+	deleted, err := s.teamRepo.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrTeamNotFound
+	}
+	return nil
+}
+
+// ListTeams возвращает страницу команд, отсортированных по названию, с числом участников.
+// cursor — непрозрачная строка next_cursor предыдущей страницы (пустая для первой страницы).
+func (s *TeamService) ListTeams(filter model.TeamListFilter, cursor string) (*model.TeamPage, error) {
+	limit, err := pageSize(filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != "" {
+		var after model.NameCursor
+		if err = decodeCursor(cursor, &after); err != nil || after.Name == "" {
+			return nil, ErrInvalidCursor
+		}
+		filter.After = after.Name
+	}
+
+	// Лишняя запись показывает, есть ли следующая страница
+	filter.Limit = limit + 1
+	teams, err := s.teamRepo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.TeamPage{Items: teams}
+	if len(teams) > limit {
+		page.Items = teams[:limit]
+		page.NextCursor, err = encodeCursor(model.NameCursor{Name: page.Items[limit-1].Name})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
 }
 
 // ДОП задание
 
-// DeactivateTeamMembers массово деактивирует всех пользователей команды
-func (s *TeamService) DeactivateTeamMembers(teamID uuid.UUID, prService *PRService) (int, error) {
+// DeactivateTeamMembers массово деактивирует всех пользователей команды.
+// Открытые ревью участников переназначаются в той же транзакции (см. PRService.planOffboarding).
+// Если участников команды или их ревью изменили параллельно, возвращает ErrConcurrentUpdate.
+func (s *TeamService) DeactivateTeamMembers(teamID uuid.UUID, prService *PRService) (int, *model.OffboardingReport, error) {
 	_, err := s.teamRepo.GetByID(teamID)
 	if err != nil {
-		return 0, errors.New("team not found")
+		return 0, nil, ErrTeamNotFound
 	}
 
 	activeUsers, err := s.userRepo.GetActiveUsersByTeam(teamID, uuid.Nil)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
 	if len(activeUsers) == 0 {
-		return 0, nil
+		return 0, nil, nil
 	}
 
-	allPRs, err := prService.GetAllPRs()
+	report, err := prService.planOffboarding(activeUsers)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
-	userIDMap := make(map[uuid.UUID]bool)
-	for _, user := range activeUsers {
-		userIDMap[user.ID] = true
+	deactivatedCount, err := s.userRepo.DeactivateTeamMembers(teamID, activeUsers, report.Replacements)
+	if errors.Is(err, repository.ErrStaleVersion) {
+		return 0, nil, ErrConcurrentUpdate
 	}
-
-	for _, pr := range allPRs {
-		if pr.Status != model.OPEN {
-			continue
-		}
-
-		for _, reviewerID := range pr.Reviewers {
-			if userIDMap[reviewerID] {
-				_, _, err = prService.ReassignReviewer(pr.ID, reviewerID)
-				if err != nil {
-					// Если не удалось переназначить (нет доступных ревьюверов),
-					// просто пропускаем - ревьювер будет деактивирован
-					continue
-				}
-			}
-		}
-	}
-
-	deactivatedCount, err := s.userRepo.DeactivateTeamMembers(teamID)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
-	return deactivatedCount, nil
+	return deactivatedCount, report, nil
 }
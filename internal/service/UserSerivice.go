@@ -3,31 +3,52 @@ package service
 import (
 	"avito-assignment/internal/model"
 	"avito-assignment/internal/repository"
+	"database/sql"
 	"errors"
+	"log"
+	"strings"
 
 	"github.com/google/uuid"
 )
 
 type UserService struct {
-	userRepo *repository.UserRepository
+	userRepo  *repository.UserRepository
+	prService *PRService
 }
 
-func NewUserService(userRepo *repository.UserRepository) *UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(userRepo *repository.UserRepository, prService *PRService) *UserService {
+	return &UserService{
+		userRepo:  userRepo,
+		prService: prService,
+	}
 }
 
 // CreateUser Создать пользователя
 func (s *UserService) CreateUser(userC *model.User) (*model.User, error) {
+	if err := validateUser(userC); err != nil {
+		return nil, err
+	}
+
 	user := &model.User{
 		ID:       uuid.New(),
 		Username: userC.Username,
 		TeamID:   userC.TeamID,
 		IsActive: userC.IsActive,
+		IsSenior: userC.IsSenior,
 	}
 	err := s.userRepo.CreateUser(user)
-	if err != nil {
+	switch {
+	case isUniqueViolation(err):
+		return nil, ErrUserExists
+	case isForeignKeyViolation(err):
+		return nil, ErrTeamNotFound
+	case err != nil:
 		return nil, err
 	}
+
+	if user.IsActive {
+		s.topUpTeamReviews(user.TeamID)
+	}
 	return user, nil
 }
 
@@ -35,21 +56,167 @@ func (s *UserService) CreateUser(userC *model.User) (*model.User, error) {
 func (s *UserService) GetUserByID(id uuid.UUID) (*model.User, error) {
 	user, err := s.userRepo.GetUserByID(id)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
 	return user, nil
 }
 
-func (s *UserService) UpdateUser(user *model.User) (*model.User, error) {
-	return s.userRepo.Update(user)
+// UpdateUser обновляет пользователя. Если пользователь деактивируется или переходит
+// в другую команду, его открытые ревью переназначаются в той же транзакции
+// (см. PRService.planOffboarding), и отчет об этом возвращается вместе с пользователем. Если пользователь стал активным
+// или перешел в другую команду, недоукомплектованные PR его команды получают ревьюверов.
+// Если версия пользователя не равна expectedVersion (repository.AnyVersion — без проверки),
+// возвращает ErrVersionMismatch и ничего не меняет.
+func (s *UserService) UpdateUser(user *model.User, expectedVersion int64) (*model.User, *model.OffboardingReport, error) {
+	if err := validateUser(user); err != nil {
+		return nil, nil, err
+	}
+
+	existing, err := s.userRepo.GetUserByID(user.ID)
+	if err != nil {
+		return nil, nil, ErrUserNotFound
+	}
+	if expectedVersion != repository.AnyVersion && existing.Version != expectedVersion {
+		return nil, nil, ErrVersionMismatch
+	}
+
+	var report *model.OffboardingReport
+	var offboarding []model.ReviewerReplacement
+	version := expectedVersion
+	if existing.IsActive && (!user.IsActive || existing.TeamID != user.TeamID) {
+		report, err = s.prService.planOffboarding([]model.User{*existing})
+		if err != nil {
+			return nil, nil, err
+		}
+		offboarding = report.Replacements
+		// Замены подобраны для прочитанного состояния пользователя
+		version = existing.Version
+	}
+
+	updated, err := s.userRepo.Update(user, version, offboarding)
+	switch {
+	case errors.Is(err, repository.ErrStaleVersion) && expectedVersion != repository.AnyVersion:
+		return nil, nil, ErrVersionMismatch
+	case errors.Is(err, repository.ErrStaleVersion):
+		return nil, nil, ErrConcurrentUpdate
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil, ErrUserNotFound
+	case isUniqueViolation(err):
+		return nil, nil, ErrUserExists
+	case isForeignKeyViolation(err):
+		return nil, nil, ErrTeamNotFound
+	case err != nil:
+		return nil, nil, err
+	}
+
+	if updated.IsActive && (!existing.IsActive || existing.TeamID != updated.TeamID) {
+		s.topUpTeamReviews(updated.TeamID)
+	}
+	return updated, report, nil
+}
+
+// userPatchFields — поля пользователя, которые можно менять через PATCH.
+var userPatchFields = map[string]bool{"username": true, "team_id": true, "is_active": true, "is_senior": true}
+
+// PatchUser применяет к пользователю JSON Merge Patch (RFC 7396): меняются только переданные поля,
+// остальные сохраняют текущие значения. Результат проверяется и сохраняется так же, как в UpdateUser.
+func (s *UserService) PatchUser(id uuid.UUID, patch []byte, expectedVersion int64) (*model.User, *model.OffboardingReport, error) {
+	existing, err := s.userRepo.GetUserByID(id)
+	if err != nil {
+		return nil, nil, ErrUserNotFound
+	}
+	if expectedVersion != repository.AnyVersion && existing.Version != expectedVersion {
+		return nil, nil, ErrVersionMismatch
+	}
+
+	user := *existing
+	if err = applyMergePatch(&user, patch, userPatchFields); err != nil {
+		return nil, nil, err
+	}
+	user.ID = id
+	return s.UpdateUser(&user, expectedVersion)
 }
 
-// Удаление пользователя
+// validateUser проверяет обязательные поля пользователя перед сохранением.
+func validateUser(user *model.User) error {
+	if strings.TrimSpace(user.Username) == "" {
+		return ErrUsernameRequired
+	}
+	if user.TeamID == uuid.Nil {
+		return ErrTeamIDRequired
+	}
+	return nil
+}
+
+// topUpTeamReviews доназначает ревьюверов PR команды, в которой появились свободные участники.
+// Ошибка доназначения не отменяет изменение пользователя и только логируется.
+func (s *UserService) topUpTeamReviews(teamID uuid.UUID) {
+	if teamID == uuid.Nil {
+		return
+	}
+	assigned, err := s.prService.TopUpTeamReviews(teamID)
+	if err != nil {
+		log.Printf("failed to top up reviewers for team %s: %v", teamID, err)
+		return
+	}
+	if assigned > 0 {
+		log.Printf("assigned %d reviewers to understaffed PRs of team %s", assigned, teamID)
+	}
+}
+
+// Удаление пользователя. Автора или ревьювера PR удалить нельзя.
 func (s *UserService) DeleteUser(id uuid.UUID) error {
-	return s.userRepo.Delete(id)
+	deleted, err := s.userRepo.Delete(id)
+	switch {
+	case isForeignKeyViolation(err):
+		return ErrUserInUse
+	case err != nil:
+		return err
+	case !deleted:
+		return ErrUserNotFound
+	}
+	return nil
 }
 
 // Получение PR'ов, где пользователь назначен ревьювером
 func (s *UserService) GetAssignedPRs(userID uuid.UUID) ([]model.PullRequest, error) {
 	return s.userRepo.GetPRsByReviewer(userID)
 }
+
+// GetReviewQueue возвращает ожидающие ревью пользователя, отсортированные по срочности
+func (s *UserService) GetReviewQueue(userID uuid.UUID) ([]model.ReviewQueueEntry, error) {
+	return s.prService.GetReviewQueue(userID)
+}
+
+// ListUsers возвращает страницу пользователей, отсортированных по имени.
+// cursor — непрозрачная строка next_cursor предыдущей страницы (пустая для первой страницы).
+func (s *UserService) ListUsers(filter model.UserListFilter, cursor string) (*model.UserPage, error) {
+	limit, err := pageSize(filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != "" {
+		var after model.NameCursor
+		if err = decodeCursor(cursor, &after); err != nil || after.Name == "" {
+			return nil, ErrInvalidCursor
+		}
+		filter.After = after.Name
+	}
+
+	// Лишняя запись показывает, есть ли следующая страница
+	filter.Limit = limit + 1
+	users, err := s.userRepo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.UserPage{Items: users}
+	if len(users) > limit {
+		page.Items = users[:limit]
+		page.NextCursor, err = encodeCursor(model.NameCursor{Name: page.Items[limit-1].Username})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
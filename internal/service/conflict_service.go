@@ -0,0 +1,68 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ConflictService реализует управление правилами конфликта интересов.
+type ConflictService struct {
+	conflictRepo *repository.ConflictRepository
+	userRepo     *repository.UserRepository
+}
+
+func NewConflictService(conflictRepo *repository.ConflictRepository, userRepo *repository.UserRepository) *ConflictService {
+	return &ConflictService{
+		conflictRepo: conflictRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// CreateConflict добавляет правило конфликта интересов
+func (s *ConflictService) CreateConflict(rule *model.ConflictRule) (*model.ConflictRule, error) {
+	if rule.ReviewerID == rule.AuthorID {
+		return nil, ErrSelfConflict
+	}
+	if _, err := s.userRepo.GetUserByID(rule.ReviewerID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	if _, err := s.userRepo.GetUserByID(rule.AuthorID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	exists, err := s.conflictRepo.HasConflict(rule.ReviewerID, rule.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrConflictRuleExists
+	}
+
+	rule.ID = uuid.New()
+	if err = s.conflictRepo.Create(rule); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrConflictRuleExists
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetAllConflicts возвращает все правила конфликта интересов
+func (s *ConflictService) GetAllConflicts() ([]model.ConflictRule, error) {
+	return s.conflictRepo.GetAll()
+}
+
+// DeleteConflict удаляет правило конфликта интересов
+func (s *ConflictService) DeleteConflict(id uuid.UUID) error {
+	deleted, err := s.conflictRepo.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrConflictRuleNotFound
+	}
+	return nil
+}
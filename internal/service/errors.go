@@ -0,0 +1,126 @@
+package service
+
+// ErrorKind описывает класс доменной ошибки; по нему слой HTTP выбирает статус ответа.
+type ErrorKind int
+
+const (
+	// KindInvalid — запрос некорректен (400).
+	KindInvalid ErrorKind = iota + 1
+	// KindNotFound — сущность не найдена (404).
+	KindNotFound
+	// KindConflict — операция противоречит текущему состоянию (409).
+	KindConflict
+	// KindForbidden — пользователю операция недоступна (403).
+	KindForbidden
+	// KindUnprocessable — запрос корректен, но не может быть выполнен в таком виде (422).
+	KindUnprocessable
+	// KindPreconditionFailed — условие запроса (If-Match) не выполнено (412).
+	KindPreconditionFailed
+)
+
+// Error — доменная ошибка сервисного слоя с машиночитаемым кодом.
+// Details дополняет ошибку данными для клиента (например, блокирующими PR).
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Details interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is позволяет сравнивать через errors.Is с сентинелом и копии, дополненные WithDetails.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code && t.Message == e.Message
+}
+
+// WithDetails возвращает копию ошибки с подробностями.
+func (e *Error) WithDetails(details interface{}) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+func newError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Сущности не найдены
+var (
+	ErrUserNotFound         = newError(KindNotFound, "USER_NOT_FOUND", "user not found")
+	ErrAuthorNotFound       = newError(KindNotFound, "AUTHOR_NOT_FOUND", "author not found")
+	ErrTeamNotFound         = newError(KindNotFound, "TEAM_NOT_FOUND", "team not found")
+	ErrPRNotFound           = newError(KindNotFound, "PR_NOT_FOUND", "pull request not found")
+	ErrDependencyNotFound   = newError(KindNotFound, "DEPENDENCY_NOT_FOUND", "dependency not found")
+	ErrRepositoryNotFound   = newError(KindNotFound, "REPOSITORY_NOT_FOUND", "repository not found")
+	ErrConflictRuleNotFound = newError(KindNotFound, "CONFLICT_RULE_NOT_FOUND", "conflict rule not found")
+)
+
+// Некорректные данные запроса
+var (
+	ErrInvalidPriority           = newError(KindInvalid, "INVALID_PRIORITY", "invalid priority")
+	ErrInvalidReviewerTier       = newError(KindInvalid, "INVALID_REVIEWER_TIER", "invalid reviewer tier")
+	ErrInvalidStatus             = newError(KindInvalid, "INVALID_STATUS", "invalid status")
+	ErrInvalidSort               = newError(KindInvalid, "INVALID_SORT", "invalid sort field")
+	ErrInvalidPageSize           = newError(KindInvalid, "INVALID_PAGE_SIZE", "limit must be between 1 and 200")
+	ErrInvalidCursor             = newError(KindInvalid, "INVALID_CURSOR", "invalid cursor")
+	ErrInvalidIdempotencyKey     = newError(KindInvalid, "INVALID_IDEMPOTENCY_KEY", "Idempotency-Key must be 1 to 255 characters")
+	ErrInvalidTeamMember         = newError(KindInvalid, "INVALID_TEAM_MEMBER", "cannot create team member")
+	ErrNotTeamMember             = newError(KindInvalid, "NOT_TEAM_MEMBER", "user is not a team member")
+	ErrRepositoryNameRequired    = newError(KindInvalid, "VALIDATION_ERROR", "repository_name is required")
+	ErrRepositoryIDRequired      = newError(KindInvalid, "VALIDATION_ERROR", "repository_id is required")
+	ErrNegativeRequiredReviewers = newError(KindInvalid, "VALIDATION_ERROR", "required_reviewers must not be negative")
+	ErrUnknownStrategy           = newError(KindInvalid, "VALIDATION_ERROR", "unknown assignment_strategy")
+	ErrSelfConflict              = newError(KindInvalid, "VALIDATION_ERROR", "conflict rule must reference two different users")
+	ErrUsernameRequired          = newError(KindInvalid, "VALIDATION_ERROR", "username is required")
+	ErrTeamIDRequired            = newError(KindInvalid, "VALIDATION_ERROR", "team_id is required")
+	ErrTeamNameRequired          = newError(KindInvalid, "VALIDATION_ERROR", "team_name is required")
+	ErrInvalidPatch              = newError(KindInvalid, "INVALID_PATCH", "invalid merge patch")
+	ErrTeamMemberRefRequired     = newError(KindInvalid, "VALIDATION_ERROR", "member must have user_id or username")
+	ErrDuplicateTeamMember       = newError(KindInvalid, "DUPLICATE_TEAM_MEMBER", "user is listed in members more than once")
+	ErrInvalidSnapshotFormat     = newError(KindInvalid, "VALIDATION_ERROR", "format must be json or csv")
+	ErrInvalidImportPolicy       = newError(KindInvalid, "VALIDATION_ERROR", "on_conflict must be skip, overwrite or fail")
+)
+
+// Конфликты с текущим состоянием
+var (
+	ErrPRMerged              = newError(KindConflict, "PR_MERGED", "pull request is already merged")
+	ErrPRBlocked             = newError(KindConflict, "PR_BLOCKED", "pull request is blocked by unmerged dependencies")
+	ErrTeamApprovalsMissing  = newError(KindConflict, "TEAM_APPROVALS_MISSING", "pull request is missing approvals from target teams")
+	ErrDependencyCycle       = newError(KindConflict, "DEPENDENCY_CYCLE", "dependency cycle detected")
+	ErrReviewerNotAssigned   = newError(KindConflict, "NOT_ASSIGNED", "reviewer not assigned to this PR")
+	ErrAlreadyReviewer       = newError(KindConflict, "ALREADY_ASSIGNED", "user is already a reviewer")
+	ErrNoCandidate           = newError(KindConflict, "NO_CANDIDATE", "no available reviewers in the team")
+	ErrNoOpenSlots           = newError(KindConflict, "NO_SLOTS", "no open review slots")
+	ErrReviewerAtCapacity    = newError(KindConflict, "AT_CAPACITY", "user has reached the open review limit")
+	ErrAuthorCannotReview    = newError(KindConflict, "AUTHOR_CANNOT_REVIEW", "author cannot review own PR")
+	ErrUserInactive          = newError(KindConflict, "USER_INACTIVE", "user is inactive")
+	ErrTeamExists            = newError(KindConflict, "TEAM_EXISTS", "team with this name already exists")
+	ErrUserExists            = newError(KindConflict, "USER_EXISTS", "user with this username already exists")
+	ErrUserInUse             = newError(KindConflict, "USER_IN_USE", "user is an author or reviewer of pull requests")
+	ErrRepositoryExists      = newError(KindConflict, "REPOSITORY_EXISTS", "repository with this name already exists")
+	ErrRepositoryInUse       = newError(KindConflict, "REPOSITORY_IN_USE", "repository has pull requests")
+	ErrConflictRuleExists    = newError(KindConflict, "CONFLICT_RULE_EXISTS", "conflict rule already exists")
+	ErrIdempotencyInProgress = newError(KindConflict, "IDEMPOTENCY_IN_PROGRESS", "request with this Idempotency-Key is still in progress")
+	ErrConcurrentUpdate      = newError(KindConflict, "CONCURRENT_UPDATE", "team, its members or their reviews were changed concurrently, retry the request")
+	ErrSnapshotConflict      = newError(KindConflict, "SNAPSHOT_CONFLICT", "snapshot record already exists")
+)
+
+// Невыполнимые запросы
+var (
+	ErrIdempotencyKeyReused = newError(KindUnprocessable, "IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used with a different request body")
+	ErrInvalidSnapshot      = newError(KindUnprocessable, "INVALID_SNAPSHOT", "snapshot is malformed or references missing records")
+)
+
+// Недоступные пользователю операции
+var (
+	ErrNotEligible = newError(KindForbidden, "NOT_ELIGIBLE", "user is not eligible to review this PR")
+)
+
+// Нарушенные условия запроса
+var (
+	ErrVersionMismatch = newError(KindPreconditionFailed, "PRECONDITION_FAILED", "resource was modified, re-read it and retry")
+)
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/repository"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+)
+
+// maxIdempotencyKeyLength — максимальная длина заголовка Idempotency-Key
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyService хранит ответы на запросы с ключом идемпотентности,
+// чтобы повтор запроса получил тот же ответ, а не выполнил операцию второй раз.
+type IdempotencyService struct {
+	repo *repository.IdempotencyRepository
+	// ttl — сколько хранится ключ и ответ на запрос
+	ttl time.Duration
+}
+
+func NewIdempotencyService(repo *repository.IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, ttl: ttl}
+}
+
+// Begin занимает ключ под запрос. Если по ключу уже сохранен ответ на тот же запрос,
+// возвращает его для повтора. Ключ с другим телом запроса — ErrIdempotencyKeyReused,
+// ключ, запрос по которому еще выполняется, — ErrIdempotencyInProgress.
+func (s *IdempotencyService) Begin(key, method, path string, body []byte) (*model.IdempotencyRecord, error) {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return nil, ErrInvalidIdempotencyKey
+	}
+
+	hash := sha256.Sum256(body)
+	record := &model.IdempotencyRecord{
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		RequestHash: hex.EncodeToString(hash[:]),
+		ExpiresAt:   time.Now().Add(s.ttl),
+	}
+	reserved, existing, err := s.repo.Reserve(record)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Занявший ключ запрос освободил его между вставкой и чтением: пробуем занять еще раз
+		reserved, existing, err = s.repo.Reserve(record)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrIdempotencyInProgress
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+	if existing.RequestHash != record.RequestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if existing.StatusCode == 0 {
+		return nil, ErrIdempotencyInProgress
+	}
+	return existing, nil
+}
+
+// Complete сохраняет ответ на запрос, занявший ключ в Begin.
+func (s *IdempotencyService) Complete(record *model.IdempotencyRecord) error {
+	return s.repo.Complete(record)
+}
+
+// Release освобождает ключ, если запрос не удалось выполнить и его можно повторить.
+func (s *IdempotencyService) Release(key, method, path string) error {
+	return s.repo.Release(key, method, path)
+}
+
+// RunCleanup периодически удаляет истекшие ключи. Блокирует вызывающую горутину.
+func (s *IdempotencyService) RunCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		deleted, err := s.repo.DeleteExpired()
+		if err != nil {
+			log.Printf("failed to delete expired idempotency keys: %v", err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("deleted %d expired idempotency keys", deleted)
+		}
+	}
+}
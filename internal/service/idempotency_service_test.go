@@ -0,0 +1,54 @@
+package service
+
+import (
+	"avito-assignment/internal/repository"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestBeginRetriesReleasedKey проверяет, что ключ, освобожденный между вставкой и чтением
+// записи, занимается повторно, а при повторной гонке запрос получает ErrIdempotencyInProgress.
+func TestBeginRetriesReleasedKey(t *testing.T) {
+	cases := []struct {
+		name     string
+		attempts []bool
+		wantErr  error
+	}{
+		{"reserved on retry", []bool{false, true}, nil},
+		{"released twice", []bool{false, false}, ErrIdempotencyInProgress},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock: %v", err)
+			}
+			defer db.Close()
+			for _, inserted := range tc.attempts {
+				mock.ExpectExec("DELETE FROM idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 0))
+				if inserted {
+					mock.ExpectExec("INSERT INTO idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 1))
+					continue
+				}
+				mock.ExpectExec("INSERT INTO idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 0))
+				// Запись уже удалена
+				mock.ExpectQuery("FROM idempotency_keys").WillReturnRows(sqlmock.NewRows([]string{"request_hash"}))
+			}
+
+			service := NewIdempotencyService(repository.NewIdempotencyRepository(db), time.Hour)
+			existing, err := service.Begin("key-1", "POST", "/api/v1/pull-request/create", []byte(`{}`))
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+			if existing != nil {
+				t.Fatalf("expected no stored response, got %+v", existing)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("queries: %v", err)
+			}
+		})
+	}
+}
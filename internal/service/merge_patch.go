@@ -0,0 +1,74 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+// applyMergePatch применяет JSON Merge Patch (RFC 7396) к JSON-представлению target
+// и записывает результат обратно в target. Менять можно только поля из patchable:
+// поле вне списка (в том числе неизвестное или только для чтения) дает ErrInvalidPatch.
+// null в патче удаляет поле, то есть сбрасывает его в нулевое значение.
+func applyMergePatch(target interface{}, patch []byte, patchable map[string]bool) error {
+	var patchDoc interface{}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return ErrInvalidPatch
+	}
+	patchObj, ok := patchDoc.(map[string]interface{})
+	if !ok {
+		return ErrInvalidPatch.WithDetails(map[string]string{"reason": "patch must be a JSON object"})
+	}
+	for field := range patchObj {
+		if !patchable[field] {
+			return ErrInvalidPatch.WithDetails(map[string]string{"field": field, "reason": "field cannot be patched"})
+		}
+	}
+
+	current, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	if err = json.Unmarshal(current, &doc); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(mergePatch(doc, patchObj))
+	if err != nil {
+		return err
+	}
+
+	// Результат декодируется в новое значение, чтобы удаленные патчем поля стали нулевыми,
+	// а не сохранили прежнее значение target
+	result := reflect.New(reflect.TypeOf(target).Elem())
+	if err = json.Unmarshal(merged, result.Interface()); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return ErrInvalidPatch.WithDetails(map[string]string{"field": typeErr.Field, "reason": "wrong value type"})
+		}
+		return ErrInvalidPatch
+	}
+	reflect.ValueOf(target).Elem().Set(result.Elem())
+	return nil
+}
+
+// mergePatch рекурсивно применяет патч-объект к документу по правилам RFC 7396.
+func mergePatch(doc interface{}, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	docObj, ok := doc.(map[string]interface{})
+	if !ok {
+		docObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(docObj, key)
+			continue
+		}
+		docObj[key] = mergePatch(docObj[key], value)
+	}
+	return docObj
+}
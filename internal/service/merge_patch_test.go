@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type patchSettings struct {
+	Theme string `json:"theme,omitempty"`
+	Size  int    `json:"size,omitempty"`
+}
+
+type patchTarget struct {
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Count    int           `json:"count"`
+	Active   bool          `json:"active"`
+	Note     *string       `json:"note"`
+	Settings patchSettings `json:"settings"`
+}
+
+// TestApplyMergePatch проверяет удаление полей через null, сохранение пропущенных полей
+// и отказ для полей вне списка и значений неверного типа.
+func TestApplyMergePatch(t *testing.T) {
+	note := "keep me"
+	base := patchTarget{
+		ID: "42", Name: "old", Count: 3, Active: true, Note: &note,
+		Settings: patchSettings{Theme: "dark", Size: 12},
+	}
+	patchable := map[string]bool{"name": true, "count": true, "active": true, "note": true, "settings": true}
+
+	cases := []struct {
+		name    string
+		patch   string
+		want    patchTarget
+		wantErr bool
+	}{
+		{"empty patch keeps everything", `{}`, base, false},
+		{"omitted fields are kept", `{"name": "new"}`, func() patchTarget {
+			p := base
+			p.Name = "new"
+			return p
+		}(), false},
+		{"null deletes a field", `{"note": null, "count": null}`, func() patchTarget {
+			p := base
+			p.Note = nil
+			p.Count = 0
+			return p
+		}(), false},
+		{"nested object is merged", `{"settings": {"size": 14}}`, func() patchTarget {
+			p := base
+			p.Settings.Size = 14
+			return p
+		}(), false},
+		{"null inside nested object deletes only that field", `{"settings": {"theme": null}}`, func() patchTarget {
+			p := base
+			p.Settings.Theme = ""
+			return p
+		}(), false},
+		{"false overrides true", `{"active": false}`, func() patchTarget {
+			p := base
+			p.Active = false
+			return p
+		}(), false},
+		{"wrong type for string", `{"name": 7}`, base, true},
+		{"wrong type for integer", `{"count": "three"}`, base, true},
+		{"wrong type for boolean", `{"active": "yes"}`, base, true},
+		{"wrong type for object", `{"settings": [1, 2]}`, base, true},
+		{"read-only field", `{"id": "43"}`, base, true},
+		{"unknown field", `{"color": "red"}`, base, true},
+		{"patch is not an object", `["name"]`, base, true},
+		{"malformed JSON", `{"name":`, base, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := base
+			err := applyMergePatch(&target, []byte(tc.patch), patchable)
+			if tc.wantErr {
+				if !errors.Is(err, ErrInvalidPatch) {
+					t.Fatalf("expected ErrInvalidPatch, got %v", err)
+				}
+				if !reflect.DeepEqual(target, base) {
+					t.Fatalf("target changed on error: %+v", target)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(target, tc.want) {
+				t.Fatalf("expected %+v, got %+v", tc.want, target)
+			}
+		})
+	}
+}
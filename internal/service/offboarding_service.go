@@ -0,0 +1,107 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// planOffboarding подбирает замены, которые снимают пользователей с открытых ревью перед
+// деактивацией или переходом в другую команду, но не применяет их: замены сохраняются
+// в одной транзакции с изменением пользователей (UserService.UpdateUser,
+// TeamService.DeactivateTeamMembers, TeamService.UpsertTeam).
+//
+// Замена ищется в прежней команде ревьювера по стратегии PR, среди кандидатов
+// не бывает других уходящих пользователей. Если замены нет, ревьювер все равно
+// снимается, а PR возвращается в очередь ревью команды. Опциональные и теневые
+// ревьюверы ничего не блокируют и просто снимаются с открытых PR без замены.
+// users — состояние пользователей до изменения.
+func (s *PRService) planOffboarding(users []model.User) (*model.OffboardingReport, error) {
+	report := &model.OffboardingReport{
+		UserIDs:      make([]uuid.UUID, 0, len(users)),
+		Replacements: make([]model.ReviewerReplacement, 0),
+	}
+
+	leaving := make([]uuid.UUID, 0, len(users))
+	for _, u := range users {
+		leaving = append(leaving, u.ID)
+	}
+	report.UserIDs = append(report.UserIDs, leaving...)
+
+	// PR, с учетом уже запланированных замен в рамках этого вызова
+	planned := make(map[uuid.UUID]*model.PullRequest)
+
+	for _, u := range users {
+		prs, err := s.userRepo.GetPRsByReviewer(u.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, assigned := range prs {
+			if assigned.Status != model.OPEN {
+				continue
+			}
+
+			pr, ok := planned[assigned.ID]
+			if !ok {
+				pr, err = s.prRepo.GetByID(assigned.ID)
+				if err != nil {
+					return nil, err
+				}
+				planned[pr.ID] = pr
+			}
+
+			if !containsID(pr.Reviewers, u.ID) {
+				role := model.RoleOptional
+				if containsID(pr.ShadowReviewers, u.ID) {
+					role = model.RoleShadow
+				}
+				report.Replacements = append(report.Replacements, model.ReviewerReplacement{
+					PRID:          pr.ID,
+					OldReviewerID: u.ID,
+					Role:          role,
+				})
+				report.Removed++
+				pr.OptionalReviewers = replaceID(pr.OptionalReviewers, u.ID, uuid.Nil)
+				pr.ShadowReviewers = replaceID(pr.ShadowReviewers, u.ID, uuid.Nil)
+				continue
+			}
+
+			newReviewerID, err := s.pickReplacement(pr, u.TeamID, leaving...)
+			if err != nil {
+				return nil, err
+			}
+
+			replacement := model.ReviewerReplacement{
+				PRID:          pr.ID,
+				OldReviewerID: u.ID,
+				Role:          model.RoleRequired,
+			}
+			if newReviewerID != uuid.Nil {
+				replacement.NewReviewerID = &newReviewerID
+				report.Reassigned++
+			} else {
+				report.ReturnedToPool++
+			}
+			report.Replacements = append(report.Replacements, replacement)
+
+			pr.Reviewers = replaceID(pr.Reviewers, u.ID, newReviewerID)
+		}
+	}
+
+	return report, nil
+}
+
+// replaceID заменяет oldID на newID в списке; если newID равен uuid.Nil, oldID удаляется.
+func replaceID(ids []uuid.UUID, oldID, newID uuid.UUID) []uuid.UUID {
+	result := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if id != oldID {
+			result = append(result, id)
+		}
+	}
+	if newID != uuid.Nil {
+		result = append(result, newID)
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+const (
+	// defaultPageSize — размер страницы списка, если limit не задан
+	defaultPageSize = 50
+	// maxPageSize — максимальный размер страницы списка
+	maxPageSize = 200
+)
+
+// pageSize возвращает размер страницы: limit или размер по умолчанию, если limit не задан.
+func pageSize(limit int) (int, error) {
+	if limit == 0 {
+		return defaultPageSize, nil
+	}
+	if limit < 0 || limit > maxPageSize {
+		return 0, ErrInvalidPageSize
+	}
+	return limit, nil
+}
+
+// encodeCursor упаковывает позицию в списке в непрозрачную для клиента строку.
+func encodeCursor(cursor interface{}) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor распаковывает строку, полученную от encodeCursor.
+func decodeCursor(cursor string, into interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, into)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"strings"
+	"time"
+)
+
+// ListPRs возвращает страницу PR по фильтрам. По умолчанию PR отсортированы по created_at.
+// cursor — непрозрачная строка next_cursor предыдущей страницы (пустая для первой страницы).
+func (s *PRService) ListPRs(filter model.PRListFilter, cursor string) (*model.PRPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = model.SortByCreatedAt
+	}
+	switch filter.Sort {
+	case model.SortByCreatedAt, model.SortByPriority, model.SortByName:
+	default:
+		return nil, ErrInvalidSort
+	}
+	if filter.Status != nil && *filter.Status != model.OPEN && *filter.Status != model.MERGED {
+		return nil, ErrInvalidStatus
+	}
+	limit, err := pageSize(filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if cursor != "" {
+		var after model.PRCursor
+		if err = decodeCursor(cursor, &after); err != nil || after.Sort != filter.Sort || after.Descending != filter.Descending ||
+			!validPRSortValue(after.Value, after.Sort) {
+			return nil, ErrInvalidCursor
+		}
+		filter.After = &after
+	}
+
+	// Лишняя запись показывает, есть ли следующая страница
+	filter.Limit = limit + 1
+	prs, err := s.prRepo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.PRPage{Items: prs}
+	if len(prs) > limit {
+		page.Items = prs[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor, err = encodeCursor(model.PRCursor{
+			Sort:       filter.Sort,
+			Descending: filter.Descending,
+			Value:      prSortValue(&last, filter.Sort),
+			ID:         last.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// prSortValue возвращает значение поля сортировки PR в текстовом виде для курсора.
+func prSortValue(pr *model.PullRequest, sort model.PRSortField) string {
+	switch sort {
+	case model.SortByPriority:
+		return string(pr.Priority)
+	case model.SortByName:
+		return pr.Title
+	default:
+		return pr.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// normalizeLabels убирает пустые метки, пробелы по краям и повторы, сохраняя порядок.
+func normalizeLabels(labels []string) []string {
+	seen := make(map[string]bool, len(labels))
+	result := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || seen[label] {
+			continue
+		}
+		seen[label] = true
+		result = append(result, label)
+	}
+	return result
+}
+
+// validPRSortValue проверяет, что значение из курсора подходит к типу поля сортировки:
+// иначе подделанный курсор дошел бы до базы и превратился в ошибку приведения типа.
+func validPRSortValue(value string, sort model.PRSortField) bool {
+	switch sort {
+	case model.SortByPriority:
+		_, ok := priorityWeights[model.PRPriority(value)]
+		return ok
+	case model.SortByName:
+		return true
+	default:
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	}
+}
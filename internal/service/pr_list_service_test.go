@@ -0,0 +1,34 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"testing"
+)
+
+// TestValidPRSortValue проверяет, что значение курсора принимается, только если подходит
+// к типу поля сортировки.
+func TestValidPRSortValue(t *testing.T) {
+	cases := []struct {
+		name  string
+		sort  model.PRSortField
+		value string
+		want  bool
+	}{
+		{"created_at timestamp", model.SortByCreatedAt, "2025-12-13T10:00:00.000123Z", true},
+		{"created_at without fraction", model.SortByCreatedAt, "2025-12-13T10:00:00Z", true},
+		{"created_at not a timestamp", model.SortByCreatedAt, "yesterday", false},
+		{"created_at empty", model.SortByCreatedAt, "", false},
+		{"known priority", model.SortByPriority, "CRITICAL", true},
+		{"unknown priority", model.SortByPriority, "URGENT", false},
+		{"lowercase priority", model.SortByPriority, "high", false},
+		{"any name", model.SortByName, "Fix login, retry", true},
+		{"empty name", model.SortByName, "", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := validPRSortValue(tc.value, tc.sort); got != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/repository"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// RepositoryService реализует бизнес-логику для работы с репозиториями.
+type RepositoryService struct {
+	repoRepo *repository.RepositoryRepository
+	teamRepo *repository.TeamRepository
+}
+
+func NewRepositoryService(repoRepo *repository.RepositoryRepository, teamRepo *repository.TeamRepository) *RepositoryService {
+	return &RepositoryService{
+		repoRepo: repoRepo,
+		teamRepo: teamRepo,
+	}
+}
+
+// CreateRepository создает репозиторий
+func (s *RepositoryService) CreateRepository(repo *model.Repository) (*model.Repository, error) {
+	existing, err := s.repoRepo.GetByName(repo.Name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrRepositoryExists
+	}
+
+	if err := s.validate(repo); err != nil {
+		return nil, err
+	}
+
+	repo.ID = uuid.New()
+	if err := s.repoRepo.Create(repo); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrRepositoryExists
+		}
+		return nil, err
+	}
+	return s.repoRepo.GetByID(repo.ID)
+}
+
+// GetRepositoryByID возвращает репозиторий по ID
+func (s *RepositoryService) GetRepositoryByID(id uuid.UUID) (*model.Repository, error) {
+	repo, err := s.repoRepo.GetByID(id)
+	if err != nil {
+		return nil, ErrRepositoryNotFound
+	}
+	return repo, nil
+}
+
+// GetAllRepositories возвращает все репозитории
+func (s *RepositoryService) GetAllRepositories() ([]model.Repository, error) {
+	return s.repoRepo.GetAll()
+}
+
+// UpdateRepository обновляет репозиторий
+func (s *RepositoryService) UpdateRepository(repo *model.Repository) (*model.Repository, error) {
+	if _, err := s.repoRepo.GetByID(repo.ID); err != nil {
+		return nil, ErrRepositoryNotFound
+	}
+
+	existing, err := s.repoRepo.GetByName(repo.Name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if existing != nil && existing.ID != repo.ID {
+		return nil, ErrRepositoryExists
+	}
+
+	if err := s.validate(repo); err != nil {
+		return nil, err
+	}
+
+	if err := s.repoRepo.Update(repo); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrRepositoryExists
+		}
+		return nil, err
+	}
+	return s.repoRepo.GetByID(repo.ID)
+}
+
+// DeleteRepository удаляет репозиторий. Репозиторий, к которому относятся PR, удалить нельзя.
+func (s *RepositoryService) DeleteRepository(id uuid.UUID) error {
+	deleted, err := s.repoRepo.Delete(id)
+	switch {
+	case isForeignKeyViolation(err):
+		return ErrRepositoryInUse
+	case err != nil:
+		return err
+	case !deleted:
+		return ErrRepositoryNotFound
+	}
+	return nil
+}
+
+// validate проверяет настройки ревью и существование команд-владельцев.
+// Пустая стратегия заменяется на стратегию по умолчанию.
+func (s *RepositoryService) validate(repo *model.Repository) error {
+	if repo.Name == "" {
+		return ErrRepositoryNameRequired
+	}
+	if repo.RequiredReviewers < 0 {
+		return ErrNegativeRequiredReviewers
+	}
+	if repo.AssignmentStrategy == "" {
+		repo.AssignmentStrategy = model.StrategyRandom
+	}
+	if !repo.AssignmentStrategy.IsValid() {
+		return ErrUnknownStrategy
+	}
+
+	repo.OwnerTeamIDs = uniqueIDs(repo.OwnerTeamIDs)
+	for _, teamID := range repo.OwnerTeamIDs {
+		if _, err := s.teamRepo.GetByID(teamID); err != nil {
+			return ErrTeamNotFound
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/repository"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// GetReviewPool возвращает очередь неназначенных ревью команды: открытые PR,
+// у которых ревьюверов меньше, чем требуется.
+func (s *PRService) GetReviewPool(teamID uuid.UUID) ([]model.ReviewPoolEntry, error) {
+	if _, err := s.teamRepo.GetByID(teamID); err != nil {
+		return nil, ErrTeamNotFound
+	}
+
+	ids, err := s.prRepo.GetUnderstaffedByTeam(teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := make([]model.ReviewPoolEntry, 0, len(ids))
+	for _, id := range ids {
+		pr, err := s.prRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		pool = append(pool, model.ReviewPoolEntry{
+			PullRequest: *pr,
+			OpenSlots:   pr.RequiredReviewers - len(pr.Reviewers),
+		})
+	}
+	return pool, nil
+}
+
+// ClaimReview назначает пользователя ревьювером PR из очереди по его собственной инициативе.
+// Взять PR может активный участник одной из команд ревью PR, который не является
+// автором, еще не назначен, не исключен из ревью этого PR (самоотвод или запрет автора)
+// и не связан с автором правилом конфликта интересов. Свободное место и лимит открытых
+// ревью пользователя проверяются под блокировкой в момент назначения.
+func (s *PRService) ClaimReview(prID, userID uuid.UUID) (*model.PullRequest, error) {
+	pr, err := s.prRepo.GetByID(prID)
+	if err != nil {
+		return nil, ErrPRNotFound
+	}
+	if pr.Status == model.MERGED {
+		return nil, ErrPRMerged
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if containsID(pr.Reviewers, userID) || containsID(pr.OptionalReviewers, userID) ||
+		containsID(pr.ShadowReviewers, userID) {
+		return nil, ErrAlreadyReviewer
+	}
+
+	excluded, err := s.prRepo.GetExcludedReviewers(prID)
+	if err != nil {
+		return nil, err
+	}
+	conflict, err := s.conflicts.HasConflict(userID, pr.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive || userID == pr.AuthorID || containsID(excluded, userID) ||
+		!containsID(pr.ReviewTeamIDs, user.TeamID) || conflict {
+		return nil, ErrNotEligible
+	}
+
+	claimed, err := s.prRepo.ClaimReviewSlot(prID, userID, s.maxOpenReviews)
+	switch {
+	case errors.Is(err, repository.ErrAlreadyReviewer):
+		return nil, ErrAlreadyReviewer
+	case errors.Is(err, repository.ErrReviewerAtCapacity):
+		return nil, ErrReviewerAtCapacity
+	case err != nil:
+		return nil, err
+	case !claimed:
+		return nil, ErrNoOpenSlots
+	}
+
+	return s.prRepo.GetByID(prID)
+}
+
+// containsID проверяет, есть ли id в списке.
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// TopUpTeamReviews доназначает ревьюверов открытым PR команды, у которых их меньше
+// требуемого. Кандидаты выбираются стратегией PR среди активных участников команды.
+// Возвращает количество новых назначений.
+func (s *PRService) TopUpTeamReviews(teamID uuid.UUID) (int, error) {
+	ids, err := s.prRepo.GetUnderstaffedByTeam(teamID)
+	if err != nil {
+		return 0, err
+	}
+
+	assigned := 0
+	for _, id := range ids {
+		pr, err := s.prRepo.GetByID(id)
+		if err != nil {
+			return assigned, err
+		}
+
+		needed := pr.RequiredReviewers - len(pr.Reviewers)
+		if needed <= 0 {
+			continue
+		}
+
+		excluded, err := s.prRepo.GetExcludedReviewers(id)
+		if err != nil {
+			return assigned, err
+		}
+		excludeIDs := []uuid.UUID{pr.AuthorID}
+		excludeIDs = append(excludeIDs, pr.Reviewers...)
+		excludeIDs = append(excludeIDs, pr.OptionalReviewers...)
+		excludeIDs = append(excludeIDs, pr.ShadowReviewers...)
+		excludeIDs = append(excludeIDs, excluded...)
+
+		candidates, err := s.userRepo.GetActiveUsersByTeamExcluding(teamID, pr.AuthorID, excludeIDs)
+		if err != nil {
+			return assigned, err
+		}
+
+		strategy, err := s.strategyForPR(pr)
+		if err != nil {
+			return assigned, err
+		}
+		picked, err := s.selectReviewers(strategy, candidates, needed)
+		if err != nil {
+			return assigned, err
+		}
+
+		for _, reviewerID := range picked {
+			// Место могло быть занято параллельно (например, через claim),
+			// а кандидат — получить ревью сверх лимита или назначение на этот PR
+			claimed, err := s.prRepo.ClaimReviewSlot(id, reviewerID, s.maxOpenReviews)
+			if errors.Is(err, repository.ErrAlreadyReviewer) || errors.Is(err, repository.ErrReviewerAtCapacity) {
+				continue
+			}
+			if err != nil {
+				return assigned, err
+			}
+			if !claimed {
+				break
+			}
+			assigned++
+		}
+	}
+
+	return assigned, nil
+}
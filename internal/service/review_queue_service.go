@@ -0,0 +1,83 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Веса оценки срочности ревью
+const (
+	urgencyAgePerHour    = 0.5 // за каждый час ожидания
+	urgencyMaxAgeHours   = 72  // дольше трех суток возраст уже не добавляет срочности
+	urgencySLAWindow     = 24  // за сутки до срока SLA срочность начинает расти
+	urgencySLAPerHour    = 2.0
+	urgencySLAOverdue    = 60
+	urgencyAuthorBlocked = 25
+)
+
+// priorityWeights задает вклад приоритета PR в оценку срочности
+var priorityWeights = map[model.PRPriority]float64{
+	model.PriorityLow:      0,
+	model.PriorityNormal:   10,
+	model.PriorityHigh:     30,
+	model.PriorityCritical: 60,
+}
+
+// GetReviewQueue возвращает ожидающие ревью пользователя, отсортированные по срочности:
+// учитываются возраст PR, приоритет, остаток SLA и то, заблокирован ли автор
+// (от PR зависят другие его открытые PR).
+func (s *PRService) GetReviewQueue(userID uuid.UUID) ([]model.ReviewQueueEntry, error) {
+	if _, err := s.userRepo.GetUserByID(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	entries, err := s.prRepo.GetReviewQueue(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range entries {
+		e := &entries[i]
+		if e.ReviewDueAt != nil {
+			remaining := e.ReviewDueAt.Sub(now).Hours()
+			e.SLARemainingHours = &remaining
+		}
+		e.Score = urgencyScore(e, now)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+// urgencyScore вычисляет оценку срочности ревью: чем больше, тем раньше его стоит сделать.
+func urgencyScore(e *model.ReviewQueueEntry, now time.Time) float64 {
+	ageHours := now.Sub(e.CreatedAt).Hours()
+	if ageHours > urgencyMaxAgeHours {
+		ageHours = urgencyMaxAgeHours
+	}
+	score := ageHours*urgencyAgePerHour + priorityWeights[e.Priority]
+
+	if e.SLARemainingHours != nil {
+		remaining := *e.SLARemainingHours
+		switch {
+		case remaining <= 0:
+			score += urgencySLAOverdue
+		case remaining < urgencySLAWindow:
+			score += (urgencySLAWindow - remaining) * urgencySLAPerHour
+		}
+	}
+
+	if e.AuthorBlocked {
+		score += urgencyAuthorBlocked
+	}
+	return score
+}
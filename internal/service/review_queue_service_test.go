@@ -0,0 +1,57 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestUrgencyScore проверяет вклад возраста, приоритета, SLA и заблокированного автора в оценку срочности.
+func TestUrgencyScore(t *testing.T) {
+	now := time.Date(2025, 12, 13, 12, 0, 0, 0, time.UTC)
+	hours := func(h float64) *float64 { return &h }
+
+	cases := []struct {
+		name  string
+		entry model.ReviewQueueEntry
+		want  float64
+	}{
+		{"fresh normal PR", model.ReviewQueueEntry{
+			Priority: model.PriorityNormal, CreatedAt: now,
+		}, 10},
+		{"age adds per hour", model.ReviewQueueEntry{
+			Priority: model.PriorityLow, CreatedAt: now.Add(-10 * time.Hour),
+		}, 5},
+		{"age is capped", model.ReviewQueueEntry{
+			Priority: model.PriorityLow, CreatedAt: now.Add(-30 * 24 * time.Hour),
+		}, urgencyMaxAgeHours * urgencyAgePerHour},
+		{"SLA far away", model.ReviewQueueEntry{
+			Priority: model.PriorityLow, CreatedAt: now, SLARemainingHours: hours(48),
+		}, 0},
+		{"SLA inside window", model.ReviewQueueEntry{
+			Priority: model.PriorityLow, CreatedAt: now, SLARemainingHours: hours(4),
+		}, (urgencySLAWindow - 4) * urgencySLAPerHour},
+		{"SLA overdue", model.ReviewQueueEntry{
+			Priority: model.PriorityLow, CreatedAt: now, SLARemainingHours: hours(-3),
+		}, urgencySLAOverdue},
+		{"SLA due exactly now", model.ReviewQueueEntry{
+			Priority: model.PriorityLow, CreatedAt: now, SLARemainingHours: hours(0),
+		}, urgencySLAOverdue},
+		{"blocked author", model.ReviewQueueEntry{
+			Priority: model.PriorityLow, CreatedAt: now, AuthorBlocked: true,
+		}, urgencyAuthorBlocked},
+		{"everything combined", model.ReviewQueueEntry{
+			Priority: model.PriorityCritical, CreatedAt: now.Add(-100 * time.Hour),
+			SLARemainingHours: hours(-1), AuthorBlocked: true,
+		}, urgencyMaxAgeHours*urgencyAgePerHour + 60 + urgencySLAOverdue + urgencyAuthorBlocked},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := urgencyScore(&tc.entry, now)
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ApproveReview фиксирует одобрение текущей ревизии PR ревьювером.
+func (s *PRService) ApproveReview(prID, reviewerID uuid.UUID) (*model.PullRequest, error) {
+	pr, err := s.prRepo.GetByID(prID)
+	if err != nil {
+		return nil, ErrPRNotFound
+	}
+	if pr.Status == model.MERGED {
+		return nil, ErrPRMerged
+	}
+
+	ok, err := s.prRepo.ApproveReview(prID, reviewerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPRMerged
+		}
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrReviewerNotAssigned
+	}
+
+	return s.prRepo.GetByID(prID)
+}
+
+// PushRevision записывает новую ревизию PR. По политике команды ревью одобрения
+// либо сбрасываются в PENDING, либо сохраняются. Каждая ревизия попадает в ленту
+// событий PR, а ревьюверы со сброшенным одобрением получают уведомление о повторном ревью.
+func (s *PRService) PushRevision(prID uuid.UUID, commitSHA *string) (*model.PullRequest, *model.PRRevision, error) {
+	pr, err := s.prRepo.GetByID(prID)
+	if err != nil {
+		return nil, nil, ErrPRNotFound
+	}
+	if pr.Status == model.MERGED {
+		return nil, nil, ErrPRMerged
+	}
+
+	resetApprovals, err := s.resetApprovalsPolicy(pr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	revision, err := s.prRepo.CreateRevision(prID, commitSHA, resetApprovals)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrPRMerged
+		}
+		return nil, nil, err
+	}
+
+	updated, err := s.prRepo.GetByID(prID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, revision, nil
+}
+
+// resetApprovalsPolicy возвращает политику сброса одобрений: берется у первой
+// команды ревью PR, а если их нет — у команды автора.
+func (s *PRService) resetApprovalsPolicy(pr *model.PullRequest) (bool, error) {
+	teamID := uuid.Nil
+	if len(pr.ReviewTeamIDs) > 0 {
+		teamID = pr.ReviewTeamIDs[0]
+	} else {
+		author, err := s.userRepo.GetUserByID(pr.AuthorID)
+		if err != nil {
+			return false, err
+		}
+		teamID = author.TeamID
+	}
+
+	team, err := s.teamRepo.GetByID(teamID)
+	if err != nil {
+		return false, err
+	}
+	return team.ResetApprovalsOnRevision, nil
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// snapshotColumn — колонка CSV-выгрузки. Имя совпадает с полем JSON записи.
+// Значения raw-колонок (числа, флаги, массивы, объекты) записываются как JSON,
+// остальные — как строки. Пустая ячейка означает null.
+type snapshotColumn struct {
+	name string
+	raw  bool
+}
+
+var snapshotColumns = map[model.SnapshotSection][]snapshotColumn{
+	model.SectionTeams: {
+		{"team_id", false}, {"team_name", false}, {"shadowing_enabled", true},
+		{"reset_approvals_on_revision", true}, {"created_at", false},
+	},
+	model.SectionRepositories: {
+		{"repository_id", false}, {"repository_name", false}, {"required_reviewers", true},
+		{"assignment_strategy", false}, {"owner_team_ids", true}, {"created_at", false},
+	},
+	model.SectionUsers: {
+		{"user_id", false}, {"username", false}, {"team_id", false},
+		{"is_active", true}, {"is_senior", true}, {"created_at", false},
+	},
+	model.SectionShadowReviewers: {
+		{"team_id", false}, {"user_id", false}, {"last_assigned_at", false},
+	},
+	model.SectionReviewerTiers: {
+		{"team_id", false}, {"min_lines", true}, {"high_risk", true},
+		{"reviewers", true}, {"senior_reviewers", true},
+	},
+	model.SectionReviewerConflicts: {
+		{"conflict_id", false}, {"reviewer_id", false}, {"author_id", false},
+		{"mutual", true}, {"reason", false}, {"created_at", false},
+	},
+	model.SectionPullRequests: {
+		{"pull_request_id", false}, {"external_id", false}, {"pull_request_name", false}, {"author_id", false},
+		{"repository_id", false}, {"required_reviewers", true}, {"lines_added", true}, {"lines_removed", true},
+		{"files_changed", true}, {"high_risk", true}, {"priority", false}, {"review_due_at", false},
+		{"labels", true}, {"status", false}, {"created_at", false}, {"merged_at", false},
+	},
+	model.SectionReviewTeams: {
+		{"pull_request_id", false}, {"team_id", false}, {"approval_required", true},
+	},
+	model.SectionDependencies: {
+		{"pull_request_id", false}, {"depends_on_id", false}, {"created_at", false},
+	},
+	model.SectionExcludedReviewers: {
+		{"pull_request_id", false}, {"user_id", false},
+	},
+	model.SectionAssignments: {
+		{"pull_request_id", false}, {"reviewer_id", false}, {"role", false},
+		{"decision", false}, {"assigned_at", false}, {"decided_at", false},
+	},
+	model.SectionRevisions: {
+		{"revision_id", false}, {"pull_request_id", false}, {"number", true},
+		{"commit_sha", false}, {"approvals_reset", true}, {"created_at", false},
+	},
+	model.SectionReviewerChanges: {
+		{"change_id", false}, {"pull_request_id", false}, {"old_reviewer_id", false},
+		{"new_reviewer_id", false}, {"kind", false}, {"reason", false}, {"created_at", false},
+	},
+	model.SectionEvents: {
+		{"event_id", false}, {"pull_request_id", false}, {"event_type", false},
+		{"payload", true}, {"created_at", false},
+	},
+}
+
+// snapshotWriter пишет выгрузку в выбранном формате; Close дописывает хвост файла.
+type snapshotWriter interface {
+	Section(section model.SnapshotSection) error
+	Record(record interface{}) error
+	Close() error
+}
+
+func newSnapshotWriter(w io.Writer, format model.SnapshotFormat) snapshotWriter {
+	if format == model.SnapshotCSV {
+		return &csvSnapshotWriter{w: csv.NewWriter(w)}
+	}
+	return &jsonSnapshotWriter{w: bufio.NewWriter(w)}
+}
+
+// jsonSnapshotWriter пишет выгрузку одним JSON-объектом по мере чтения записей,
+// не собирая ее в памяти.
+type jsonSnapshotWriter struct {
+	w        *bufio.Writer
+	sections int
+	records  int
+}
+
+func (j *jsonSnapshotWriter) Section(section model.SnapshotSection) error {
+	prefix := "{\n"
+	if j.sections > 0 {
+		prefix = "\n],\n"
+	}
+	j.sections++
+	j.records = 0
+	_, err := fmt.Fprintf(j.w, "%s%s: [", prefix, strconv.Quote(string(section)))
+	return err
+}
+
+func (j *jsonSnapshotWriter) Record(record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	separator := "\n"
+	if j.records > 0 {
+		separator = ",\n"
+	}
+	j.records++
+	if _, err = j.w.WriteString(separator); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonSnapshotWriter) Close() error {
+	tail := "\n]\n}\n"
+	if j.sections == 0 {
+		tail = "{}\n"
+	}
+	if _, err := j.w.WriteString(tail); err != nil {
+		return err
+	}
+	return j.w.Flush()
+}
+
+// csvSnapshotWriter пишет выгрузку в CSV: строка "#раздел,колонки..." перед каждым разделом,
+// затем записи раздела с его именем в первой колонке.
+type csvSnapshotWriter struct {
+	w       *csv.Writer
+	section model.SnapshotSection
+}
+
+func (c *csvSnapshotWriter) Section(section model.SnapshotSection) error {
+	c.section = section
+	header := []string{"#" + string(section)}
+	for _, column := range snapshotColumns[section] {
+		header = append(header, column.name)
+	}
+	return c.w.Write(header)
+}
+
+func (c *csvSnapshotWriter) Record(record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	row := []string{string(c.section)}
+	for _, column := range snapshotColumns[c.section] {
+		value := fields[column.name]
+		switch {
+		case len(value) == 0 || string(value) == "null":
+			row = append(row, "")
+		case column.raw:
+			row = append(row, string(value))
+		default:
+			var s string
+			if err = json.Unmarshal(value, &s); err != nil {
+				return err
+			}
+			row = append(row, s)
+		}
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvSnapshotWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// snapshotRecordFunc получает очередную запись раздела: decode декодирует ее в переданную структуру.
+type snapshotRecordFunc func(section model.SnapshotSection, decode func(into interface{}) error) error
+
+// errMalformedSnapshot оборачивает ошибки разбора файла выгрузки.
+var errMalformedSnapshot = errors.New("malformed snapshot")
+
+// readSnapshot читает выгрузку в выбранном формате и передает записи в onRecord по одной.
+func readSnapshot(r io.Reader, format model.SnapshotFormat, onRecord snapshotRecordFunc) error {
+	if format == model.SnapshotCSV {
+		return readCSVSnapshot(r, onRecord)
+	}
+	return readJSONSnapshot(r, onRecord)
+}
+
+func readJSONSnapshot(r io.Reader, onRecord snapshotRecordFunc) error {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("%w: %v", errMalformedSnapshot, err)
+		}
+		section := model.SnapshotSection(token.(string))
+		if err = expectDelim(decoder, '['); err != nil {
+			return err
+		}
+		for decoder.More() {
+			err = onRecord(section, func(into interface{}) error {
+				return decoder.Decode(into)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if err = expectDelim(decoder, ']'); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, '}')
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errMalformedSnapshot, err)
+	}
+	if token != delim {
+		return fmt.Errorf("%w: expected %q", errMalformedSnapshot, delim)
+	}
+	return nil
+}
+
+func readCSVSnapshot(r io.Reader, onRecord snapshotRecordFunc) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var section model.SnapshotSection
+	var columns []snapshotColumn
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", errMalformedSnapshot, err)
+		}
+
+		if strings.HasPrefix(row[0], "#") {
+			section = model.SnapshotSection(strings.TrimPrefix(row[0], "#"))
+			columns, err = headerColumns(section, row[1:])
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if model.SnapshotSection(row[0]) != section || len(row)-1 != len(columns) {
+			return fmt.Errorf("%w: row does not match the %q section header", errMalformedSnapshot, row[0])
+		}
+
+		fields := make(map[string]json.RawMessage, len(columns))
+		for n, column := range columns {
+			cell := row[n+1]
+			switch {
+			case cell == "":
+				fields[column.name] = json.RawMessage("null")
+			case column.raw:
+				fields[column.name] = json.RawMessage(cell)
+			default:
+				fields[column.name] = json.RawMessage(strconv.Quote(cell))
+			}
+		}
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errMalformedSnapshot, err)
+		}
+		err = onRecord(section, func(into interface{}) error {
+			return json.Unmarshal(data, into)
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// headerColumns сопоставляет колонки заголовка раздела известным колонкам; порядок колонок может быть любым.
+func headerColumns(section model.SnapshotSection, names []string) ([]snapshotColumn, error) {
+	known, ok := snapshotColumns[section]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown section %q", errMalformedSnapshot, section)
+	}
+	columns := make([]snapshotColumn, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, column := range known {
+			if column.name == name {
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: unknown column %q in section %q", errMalformedSnapshot, name, section)
+		}
+	}
+	return columns, nil
+}
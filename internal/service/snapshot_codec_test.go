@@ -0,0 +1,221 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// snapshotSectionRecords — записи одного раздела выгрузки в тестовых данных.
+type snapshotSectionRecords struct {
+	section model.SnapshotSection
+	records []interface{}
+}
+
+// snapshotFixture возвращает записи каждого раздела в порядке model.SnapshotSections. Значения подобраны так,
+// чтобы проверить nil-указатели, пустую команду пользователя, массивы и строки
+// с запятыми, кавычками и переводом строки.
+func snapshotFixture() []snapshotSectionRecords {
+	at := func(minute int) time.Time { return time.Date(2025, 12, 13, 10, minute, 0, 123000, time.UTC) }
+	due := at(50)
+	externalID := "GH-42"
+	teamID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	repoID := uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	authorID := uuid.MustParse("33333333-3333-3333-3333-333333333333")
+	reviewerID := uuid.MustParse("44444444-4444-4444-4444-444444444444")
+	prID := uuid.MustParse("55555555-5555-5555-5555-555555555555")
+	eventID := uuid.MustParse("66666666-6666-6666-6666-666666666666")
+	baseID := uuid.MustParse("77777777-7777-7777-7777-777777777777")
+	otherTeamID := uuid.MustParse("88888888-8888-8888-8888-888888888888")
+	conflictID := uuid.MustParse("99999999-9999-9999-9999-999999999999")
+	revisionID := uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+	changeID := uuid.MustParse("bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb")
+	commitSHA := "3f2a9c1"
+
+	return []snapshotSectionRecords{
+		{model.SectionTeams, []interface{}{
+			model.SnapshotTeam{ID: teamID, Name: `backend, "core"`, ShadowingEnabled: true, CreatedAt: at(0)},
+			model.SnapshotTeam{ID: otherTeamID, Name: "payments", ResetApprovalsOnRevision: true, CreatedAt: at(0)},
+		}},
+		{model.SectionRepositories, []interface{}{
+			model.SnapshotRepository{ID: repoID, Name: "api", RequiredReviewers: 2,
+				AssignmentStrategy: model.StrategyLeastLoaded, OwnerTeamIDs: []uuid.UUID{teamID}, CreatedAt: at(1)},
+		}},
+		{model.SectionUsers, []interface{}{
+			model.SnapshotUser{ID: authorID, Username: "alice", TeamID: &teamID, IsActive: true, IsSenior: true, CreatedAt: at(2)},
+			model.SnapshotUser{ID: reviewerID, Username: "bob", IsActive: false, CreatedAt: at(3)},
+		}},
+		{model.SectionShadowReviewers, []interface{}{
+			model.SnapshotShadowReviewer{TeamID: teamID, UserID: reviewerID},
+			model.SnapshotShadowReviewer{TeamID: otherTeamID, UserID: authorID, LastAssignedAt: &due},
+		}},
+		{model.SectionReviewerTiers, []interface{}{
+			model.SnapshotReviewerTier{TeamID: teamID, MinLines: 0, Reviewers: 1},
+			model.SnapshotReviewerTier{TeamID: teamID, MinLines: 500, HighRisk: true, Reviewers: 3, SeniorReviewers: 1},
+		}},
+		{model.SectionReviewerConflicts, []interface{}{
+			model.SnapshotReviewerConflict{ID: conflictID, ReviewerID: reviewerID, AuthorID: authorID, Mutual: true,
+				Reason: "same household, \"family\"", CreatedAt: at(3)},
+		}},
+		{model.SectionPullRequests, []interface{}{
+			model.SnapshotPullRequest{ID: prID, ExternalID: &externalID, Title: "Fix login,\nretry \"once\"",
+				AuthorID: authorID, RepositoryID: repoID, RequiredReviewers: 1, LinesAdded: 10, LinesRemoved: 3,
+				FilesChanged: 2, HighRisk: true, Priority: model.PriorityHigh, ReviewDueAt: &due,
+				Labels: []string{"bug", "needs review"}, Status: model.OPEN, CreatedAt: at(4)},
+			model.SnapshotPullRequest{ID: baseID, Title: "Base", AuthorID: authorID, RepositoryID: repoID,
+				RequiredReviewers: 1, Priority: model.PriorityNormal, Labels: []string{}, Status: model.MERGED,
+				CreatedAt: at(4), MergedAt: &due},
+		}},
+		{model.SectionReviewTeams, []interface{}{
+			model.SnapshotReviewTeam{PRID: prID, TeamID: teamID},
+			model.SnapshotReviewTeam{PRID: prID, TeamID: otherTeamID, ApprovalRequired: true},
+		}},
+		{model.SectionDependencies, []interface{}{
+			model.SnapshotDependency{PRID: prID, DependsOnID: baseID, CreatedAt: at(5)},
+		}},
+		{model.SectionExcludedReviewers, []interface{}{
+			model.SnapshotExcludedReviewer{PRID: prID, UserID: authorID},
+		}},
+		{model.SectionAssignments, []interface{}{
+			model.SnapshotAssignment{PRID: prID, ReviewerID: reviewerID, Role: model.RoleRequired,
+				Decision: model.DecisionPending, AssignedAt: at(5)},
+		}},
+		{model.SectionRevisions, []interface{}{
+			model.SnapshotRevision{ID: revisionID, PRID: prID, Number: 1, CommitSHA: &commitSHA, CreatedAt: at(5)},
+		}},
+		{model.SectionReviewerChanges, []interface{}{
+			model.SnapshotReviewerChange{ID: changeID, PRID: prID, OldReviewerID: authorID, NewReviewerID: &reviewerID,
+				Kind: model.ReviewerReassigned, Reason: "vacation", CreatedAt: at(5)},
+		}},
+		{model.SectionEvents, []interface{}{
+			model.SnapshotEvent{ID: eventID, PRID: prID, Type: model.EventReviewApproved,
+				Payload:   json.RawMessage(`{"reviewer_id":"44444444-4444-4444-4444-444444444444","revision":1}`),
+				CreatedAt: at(6)},
+		}},
+	}
+}
+
+// writeSnapshotFixture пишет разделы fixture в выбранном формате.
+func writeSnapshotFixture(t *testing.T, fixture []snapshotSectionRecords, format model.SnapshotFormat) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := newSnapshotWriter(&buf, format)
+	for _, s := range fixture {
+		if err := writer.Section(s.section); err != nil {
+			t.Fatalf("write section %s: %v", s.section, err)
+		}
+		for _, record := range s.records {
+			if err := writer.Record(record); err != nil {
+				t.Fatalf("write %s record: %v", s.section, err)
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// decodeSnapshotRecord декодирует запись раздела в структуру выгрузки этого раздела.
+func decodeSnapshotRecord(section model.SnapshotSection, decode func(into interface{}) error) (interface{}, error) {
+	var into interface{}
+	switch section {
+	case model.SectionTeams:
+		into = &model.SnapshotTeam{}
+	case model.SectionRepositories:
+		into = &model.SnapshotRepository{}
+	case model.SectionUsers:
+		into = &model.SnapshotUser{}
+	case model.SectionShadowReviewers:
+		into = &model.SnapshotShadowReviewer{}
+	case model.SectionReviewerTiers:
+		into = &model.SnapshotReviewerTier{}
+	case model.SectionReviewerConflicts:
+		into = &model.SnapshotReviewerConflict{}
+	case model.SectionPullRequests:
+		into = &model.SnapshotPullRequest{}
+	case model.SectionReviewTeams:
+		into = &model.SnapshotReviewTeam{}
+	case model.SectionDependencies:
+		into = &model.SnapshotDependency{}
+	case model.SectionExcludedReviewers:
+		into = &model.SnapshotExcludedReviewer{}
+	case model.SectionAssignments:
+		into = &model.SnapshotAssignment{}
+	case model.SectionRevisions:
+		into = &model.SnapshotRevision{}
+	case model.SectionReviewerChanges:
+		into = &model.SnapshotReviewerChange{}
+	default:
+		into = &model.SnapshotEvent{}
+	}
+	if err := decode(into); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(into).Elem().Interface(), nil
+}
+
+// TestSnapshotCodecRoundTrip проверяет, что записанная выгрузка читается обратно без потерь
+// в обоих форматах.
+func TestSnapshotCodecRoundTrip(t *testing.T) {
+	fixture := snapshotFixture()
+
+	for _, format := range []model.SnapshotFormat{model.SnapshotJSON, model.SnapshotCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			data := writeSnapshotFixture(t, fixture, format)
+
+			var got []snapshotSectionRecords
+			err := readSnapshot(bytes.NewReader(data), format, func(section model.SnapshotSection, decode func(into interface{}) error) error {
+				record, err := decodeSnapshotRecord(section, decode)
+				if err != nil {
+					return err
+				}
+				if len(got) == 0 || got[len(got)-1].section != section {
+					got = append(got, snapshotSectionRecords{section: section})
+				}
+				got[len(got)-1].records = append(got[len(got)-1].records, record)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("read snapshot: %v\n%s", err, data)
+			}
+			if !reflect.DeepEqual(got, fixture) {
+				t.Fatalf("round trip mismatch\nwant %+v\ngot  %+v\nfile:\n%s", fixture, got, data)
+			}
+		})
+	}
+}
+
+// TestReadSnapshotRejectsMalformedInput проверяет, что испорченный файл дает errMalformedSnapshot.
+func TestReadSnapshotRejectsMalformedInput(t *testing.T) {
+	cases := []struct {
+		name   string
+		format model.SnapshotFormat
+		input  string
+	}{
+		{"json is not an object", model.SnapshotJSON, `[]`},
+		{"json section is not an array", model.SnapshotJSON, `{"teams": {}}`},
+		{"json object is not closed", model.SnapshotJSON, `{"teams": []`},
+		{"csv unknown section", model.SnapshotCSV, "#projects,project_id\n"},
+		{"csv unknown column", model.SnapshotCSV, "#teams,team_id,color\n"},
+		{"csv row without header", model.SnapshotCSV, "teams,11111111-1111-1111-1111-111111111111\n"},
+		{"csv row of another section", model.SnapshotCSV, "#teams,team_id\nusers,11111111-1111-1111-1111-111111111111\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := readSnapshot(bytes.NewBufferString(tc.input), tc.format, func(section model.SnapshotSection, decode func(into interface{}) error) error {
+				_, err := decodeSnapshotRecord(section, decode)
+				return err
+			})
+			if !errors.Is(err, errMalformedSnapshot) {
+				t.Fatalf("expected errMalformedSnapshot, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/repository"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// SnapshotService выгружает данные сервиса в файл и загружает их обратно.
+type SnapshotService struct {
+	repo *repository.SnapshotRepository
+}
+
+// NewSnapshotService создает новый экземпляр SnapshotService.
+func NewSnapshotService(repo *repository.SnapshotRepository) *SnapshotService {
+	return &SnapshotService{repo: repo}
+}
+
+// Export пишет в w все разделы model.SnapshotSections (команды с их настройками, репозитории,
+// пользователей, правила конфликта интересов, PR со связанными строками и события PR)
+// в выбранном формате. Данные читаются из одного согласованного снимка базы и не собираются в памяти.
+func (s *SnapshotService) Export(w io.Writer, format model.SnapshotFormat) error {
+	if !format.IsValid() {
+		return ErrInvalidSnapshotFormat
+	}
+	writer := newSnapshotWriter(w, format)
+	if err := s.repo.Export(writer); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// Import загружает выгрузку в одной транзакции: при любой ошибке база остается без изменений.
+// Разделы должны идти в порядке model.SnapshotSections (некоторые могут отсутствовать).
+// Записи, которые уже есть в базе, обрабатываются согласно policy.
+func (s *SnapshotService) Import(r io.Reader, format model.SnapshotFormat, policy model.ImportPolicy) (*model.ImportReport, error) {
+	if !format.IsValid() {
+		return nil, ErrInvalidSnapshotFormat
+	}
+	if !policy.IsValid() {
+		return nil, ErrInvalidImportPolicy
+	}
+
+	imp, err := s.repo.BeginImport(policy)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// После Commit откат возвращает sql.ErrTxDone, это ожидаемо
+		_ = imp.Rollback()
+	}()
+
+	report := &model.ImportReport{Policy: policy, Sections: make(map[model.SnapshotSection]*model.ImportCounts)}
+	order := sectionOrder()
+	current := -1
+	index := 0
+	var counts *model.ImportCounts
+
+	err = readSnapshot(r, format, func(section model.SnapshotSection, decode func(into interface{}) error) error {
+		if counts == nil || section != model.SnapshotSections[current] {
+			position, ok := order[section]
+			if !ok || position <= current {
+				return invalidSnapshot(section, -1, "unknown or out-of-order section")
+			}
+			current = position
+			index = 0
+			counts = &model.ImportCounts{}
+			report.Sections[section] = counts
+		}
+
+		outcome, err := importRecord(imp, section, decode)
+		if err != nil {
+			return snapshotRecordError(section, index, err)
+		}
+		counts.Add(outcome)
+		index++
+		return nil
+	})
+	if errors.Is(err, errMalformedSnapshot) {
+		return nil, ErrInvalidSnapshot.WithDetails(map[string]string{"reason": err.Error()})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = imp.Commit(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// importRecord декодирует запись раздела и загружает ее.
+func importRecord(imp *repository.SnapshotImport, section model.SnapshotSection, decode func(into interface{}) error) (model.ImportOutcome, error) {
+	switch section {
+	case model.SectionTeams:
+		var t model.SnapshotTeam
+		if err := decode(&t); err != nil {
+			return 0, err
+		}
+		return imp.Team(t)
+	case model.SectionRepositories:
+		var repo model.SnapshotRepository
+		if err := decode(&repo); err != nil {
+			return 0, err
+		}
+		return imp.Repository(repo)
+	case model.SectionUsers:
+		var u model.SnapshotUser
+		if err := decode(&u); err != nil {
+			return 0, err
+		}
+		return imp.User(u)
+	case model.SectionShadowReviewers:
+		var sr model.SnapshotShadowReviewer
+		if err := decode(&sr); err != nil {
+			return 0, err
+		}
+		return imp.ShadowReviewer(sr)
+	case model.SectionReviewerTiers:
+		var t model.SnapshotReviewerTier
+		if err := decode(&t); err != nil {
+			return 0, err
+		}
+		return imp.ReviewerTier(t)
+	case model.SectionReviewerConflicts:
+		var c model.SnapshotReviewerConflict
+		if err := decode(&c); err != nil {
+			return 0, err
+		}
+		return imp.ReviewerConflict(c)
+	case model.SectionPullRequests:
+		var p model.SnapshotPullRequest
+		if err := decode(&p); err != nil {
+			return 0, err
+		}
+		return imp.PullRequest(p)
+	case model.SectionReviewTeams:
+		var t model.SnapshotReviewTeam
+		if err := decode(&t); err != nil {
+			return 0, err
+		}
+		return imp.ReviewTeam(t)
+	case model.SectionDependencies:
+		var d model.SnapshotDependency
+		if err := decode(&d); err != nil {
+			return 0, err
+		}
+		return imp.Dependency(d)
+	case model.SectionExcludedReviewers:
+		var e model.SnapshotExcludedReviewer
+		if err := decode(&e); err != nil {
+			return 0, err
+		}
+		return imp.ExcludedReviewer(e)
+	case model.SectionAssignments:
+		var a model.SnapshotAssignment
+		if err := decode(&a); err != nil {
+			return 0, err
+		}
+		return imp.Assignment(a)
+	case model.SectionRevisions:
+		var rev model.SnapshotRevision
+		if err := decode(&rev); err != nil {
+			return 0, err
+		}
+		return imp.Revision(rev)
+	case model.SectionReviewerChanges:
+		var c model.SnapshotReviewerChange
+		if err := decode(&c); err != nil {
+			return 0, err
+		}
+		return imp.ReviewerChange(c)
+	default:
+		var e model.SnapshotEvent
+		if err := decode(&e); err != nil {
+			return 0, err
+		}
+		return imp.Event(e)
+	}
+}
+
+// snapshotRecordError переводит ошибку загрузки записи в ошибку сервиса с указанием раздела и номера записи.
+func snapshotRecordError(section model.SnapshotSection, index int, err error) error {
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, repository.ErrSnapshotConflict), isUniqueViolation(err):
+		return ErrSnapshotConflict.WithDetails(map[string]string{
+			"section": string(section),
+			"index":   strconv.Itoa(index),
+		})
+	case errors.As(err, &pqErr):
+		// Классы 22 (некорректные данные) и 23 (нарушение ограничений) — ошибки самой выгрузки
+		if class := pqErr.Code.Class(); class == "22" || class == "23" {
+			return invalidSnapshot(section, index, pqErr.Message)
+		}
+		return err
+	case errors.Is(err, errMalformedSnapshot):
+		return err
+	default:
+		// Остальные ошибки возникают при декодировании записи
+		return invalidSnapshot(section, index, err.Error())
+	}
+}
+
+func invalidSnapshot(section model.SnapshotSection, index int, reason string) error {
+	details := map[string]string{"section": string(section), "reason": reason}
+	if index >= 0 {
+		details["index"] = strconv.Itoa(index)
+	}
+	return ErrInvalidSnapshot.WithDetails(details)
+}
+
+// sectionOrder возвращает позиции разделов в model.SnapshotSections.
+func sectionOrder() map[model.SnapshotSection]int {
+	order := make(map[model.SnapshotSection]int, len(model.SnapshotSections))
+	for n, section := range model.SnapshotSections {
+		order[section] = n
+	}
+	return order
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/repository"
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+// snapshotRecordColumns возвращает таблицу записи выгрузки и значения ее колонок в порядке,
+// в котором их читает экспорт и пишет импорт.
+func snapshotRecordColumns(record interface{}) (string, []interface{}) {
+	switch r := record.(type) {
+	case model.SnapshotTeam:
+		return "teams", []interface{}{r.ID, r.Name, r.ShadowingEnabled, r.ResetApprovalsOnRevision, r.CreatedAt}
+	case model.SnapshotRepository:
+		return "repositories", []interface{}{r.ID, r.Name, r.RequiredReviewers, r.AssignmentStrategy, r.CreatedAt}
+	case model.SnapshotUser:
+		return "users", []interface{}{r.ID, r.Username, r.TeamID, r.IsActive, r.IsSenior, r.CreatedAt}
+	case model.SnapshotShadowReviewer:
+		return "team_shadow_reviewers", []interface{}{r.TeamID, r.UserID, r.LastAssignedAt}
+	case model.SnapshotReviewerTier:
+		return "team_reviewer_tiers", []interface{}{r.TeamID, r.MinLines, r.HighRisk, r.Reviewers, r.SeniorReviewers}
+	case model.SnapshotReviewerConflict:
+		return "reviewer_conflicts", []interface{}{r.ID, r.ReviewerID, r.AuthorID, r.Mutual, r.Reason, r.CreatedAt}
+	case model.SnapshotPullRequest:
+		return "pull_requests", []interface{}{r.ID, r.ExternalID, r.Title, r.AuthorID, r.RepositoryID, r.RequiredReviewers,
+			r.LinesAdded, r.LinesRemoved, r.FilesChanged, r.HighRisk, r.Priority, r.ReviewDueAt, pq.StringArray(r.Labels),
+			r.Status, r.CreatedAt, r.MergedAt}
+	case model.SnapshotReviewTeam:
+		return "pr_review_teams", []interface{}{r.PRID, r.TeamID, r.ApprovalRequired}
+	case model.SnapshotDependency:
+		return "pr_dependencies", []interface{}{r.PRID, r.DependsOnID, r.CreatedAt}
+	case model.SnapshotExcludedReviewer:
+		return "pr_excluded_reviewers", []interface{}{r.PRID, r.UserID}
+	case model.SnapshotAssignment:
+		return "pr_reviewers", []interface{}{r.PRID, r.ReviewerID, r.Role, r.Decision, r.AssignedAt, r.DecidedAt}
+	case model.SnapshotRevision:
+		return "pr_revisions", []interface{}{r.ID, r.PRID, r.Number, r.CommitSHA, r.ApprovalsReset, r.CreatedAt}
+	case model.SnapshotReviewerChange:
+		return "pr_reviewer_changes", []interface{}{r.ID, r.PRID, r.OldReviewerID, r.NewReviewerID, r.Kind, r.Reason, r.CreatedAt}
+	case model.SnapshotEvent:
+		return "pr_events", []interface{}{r.ID, r.PRID, r.Type, []byte(r.Payload), r.CreatedAt}
+	}
+	panic("unknown snapshot record")
+}
+
+// driverValues приводит значения к виду, в котором их возвращает драйвер базы.
+func driverValues(t *testing.T, values []interface{}) []driver.Value {
+	t.Helper()
+	converted := make([]driver.Value, 0, len(values))
+	for _, value := range values {
+		v, err := driver.DefaultParameterConverter.ConvertValue(value)
+		if err != nil {
+			t.Fatalf("convert %v: %v", value, err)
+		}
+		converted = append(converted, v)
+	}
+	return converted
+}
+
+// expectSnapshotExport ожидает чтение всех разделов fixture в транзакции экспорта.
+func expectSnapshotExport(t *testing.T, mock sqlmock.Sqlmock, fixture []snapshotSectionRecords) {
+	t.Helper()
+	mock.ExpectBegin()
+	for _, s := range fixture {
+		var rows *sqlmock.Rows
+		for _, record := range s.records {
+			table, values := snapshotRecordColumns(record)
+			if repo, ok := record.(model.SnapshotRepository); ok {
+				// Команды-владельцы читаются массивом перед created_at
+				owners := make([]string, 0, len(repo.OwnerTeamIDs))
+				for _, id := range repo.OwnerTeamIDs {
+					owners = append(owners, id.String())
+				}
+				values = append(values[:4:4], pq.StringArray(owners), repo.CreatedAt)
+			}
+			if rows == nil {
+				// Имена колонок не важны: экспорт читает их по позиции
+				columns := make([]string, len(values))
+				for n := range columns {
+					columns[n] = fmt.Sprintf("%s_%d", table, n)
+				}
+				rows = sqlmock.NewRows(columns)
+			}
+			rows.AddRow(driverValues(t, values)...)
+		}
+		mock.ExpectQuery(`FROM ` + regexp.QuoteMeta(exportTable(s.section))).WillReturnRows(rows)
+	}
+	mock.ExpectRollback()
+}
+
+// exportTables — таблицы, из которых выгружаются разделы с именем, отличным от таблицы.
+var exportTables = map[model.SnapshotSection]string{
+	model.SectionShadowReviewers:   "team_shadow_reviewers",
+	model.SectionReviewerTiers:     "team_reviewer_tiers",
+	model.SectionReviewTeams:       "pr_review_teams",
+	model.SectionDependencies:      "pr_dependencies",
+	model.SectionExcludedReviewers: "pr_excluded_reviewers",
+	model.SectionAssignments:       "pr_reviewers",
+	model.SectionRevisions:         "pr_revisions",
+	model.SectionReviewerChanges:   "pr_reviewer_changes",
+	model.SectionEvents:            "pr_events",
+}
+
+// exportTable возвращает таблицу, из которой выгружается раздел.
+func exportTable(section model.SnapshotSection) string {
+	if table, ok := exportTables[section]; ok {
+		return table
+	}
+	return string(section)
+}
+
+// expectSnapshotImport ожидает загрузку записей fixture с политикой policy. existing означает,
+// что каждая запись уже есть в базе. Возвращает ожидаемый отчет загрузки.
+func expectSnapshotImport(mock sqlmock.Sqlmock, fixture []snapshotSectionRecords, policy model.ImportPolicy, existing bool) *model.ImportReport {
+	report := &model.ImportReport{Policy: policy, Sections: make(map[model.SnapshotSection]*model.ImportCounts)}
+	mock.ExpectBegin()
+	for _, s := range fixture {
+		counts := &model.ImportCounts{}
+		report.Sections[s.section] = counts
+		for _, record := range s.records {
+			table, values := snapshotRecordColumns(record)
+			args := make([]driver.Value, len(values))
+			for n, value := range values {
+				args[n] = value
+			}
+			insert := regexp.QuoteMeta("INSERT INTO "+table+" (") + ".*"
+
+			outcome := model.ImportInserted
+			switch {
+			case policy == model.ImportOverwrite:
+				if existing {
+					outcome = model.ImportUpdated
+				}
+				mock.ExpectQuery(insert + `ON CONFLICT \(.+\) DO UPDATE`).WithArgs(args...).
+					WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(!existing))
+			case existing:
+				outcome = model.ImportSkipped
+				mock.ExpectExec(insert + "ON CONFLICT DO NOTHING").WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, 0))
+			default:
+				mock.ExpectExec(insert + "ON CONFLICT DO NOTHING").WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			if policy == model.ImportFail && existing {
+				// Первая же существующая запись отменяет загрузку
+				mock.ExpectRollback()
+				return nil
+			}
+
+			if repo, ok := record.(model.SnapshotRepository); ok && outcome != model.ImportSkipped {
+				mock.ExpectExec("DELETE FROM repository_teams").WithArgs(repo.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+				for _, teamID := range repo.OwnerTeamIDs {
+					mock.ExpectExec("INSERT INTO repository_teams").WithArgs(repo.ID, teamID).WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+			}
+			counts.Add(outcome)
+		}
+	}
+	mock.ExpectCommit()
+	return report
+}
+
+// TestSnapshotExportImportRoundTrip выгружает данные и загружает выгрузку обратно в обоих форматах
+// со всеми политиками конфликтов: загружаемые значения должны совпасть с выгруженными,
+// а отчет — с политикой.
+func TestSnapshotExportImportRoundTrip(t *testing.T) {
+	fixture := snapshotFixture()
+
+	cases := []struct {
+		name     string
+		policy   model.ImportPolicy
+		existing bool
+	}{
+		{"skip into empty base", model.ImportSkip, false},
+		{"skip existing records", model.ImportSkip, true},
+		{"overwrite into empty base", model.ImportOverwrite, false},
+		{"overwrite existing records", model.ImportOverwrite, true},
+		{"fail into empty base", model.ImportFail, false},
+		{"fail on existing record", model.ImportFail, true},
+	}
+	for _, format := range []model.SnapshotFormat{model.SnapshotJSON, model.SnapshotCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock: %v", err)
+			}
+			defer db.Close()
+			expectSnapshotExport(t, mock, fixture)
+
+			var exported bytes.Buffer
+			if err = NewSnapshotService(repository.NewSnapshotRepository(db)).Export(&exported, format); err != nil {
+				t.Fatalf("export: %v", err)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("export queries: %v", err)
+			}
+			if want := writeSnapshotFixture(t, fixture, format); !bytes.Equal(exported.Bytes(), want) {
+				t.Fatalf("unexpected export\nwant:\n%s\ngot:\n%s", want, exported.Bytes())
+			}
+
+			for _, tc := range cases {
+				t.Run(tc.name, func(t *testing.T) {
+					db, mock, err := sqlmock.New()
+					if err != nil {
+						t.Fatalf("sqlmock: %v", err)
+					}
+					defer db.Close()
+					want := expectSnapshotImport(mock, fixture, tc.policy, tc.existing)
+
+					service := NewSnapshotService(repository.NewSnapshotRepository(db))
+					report, err := service.Import(bytes.NewReader(exported.Bytes()), format, tc.policy)
+					if want == nil {
+						var serviceErr *Error
+						if !errors.As(err, &serviceErr) || !errors.Is(err, ErrSnapshotConflict) {
+							t.Fatalf("expected ErrSnapshotConflict, got %v", err)
+						}
+						wantDetails := map[string]string{"section": string(model.SectionTeams), "index": "0"}
+						if !reflect.DeepEqual(serviceErr.Details, wantDetails) {
+							t.Fatalf("expected details %v, got %v", wantDetails, serviceErr.Details)
+						}
+					} else {
+						if err != nil {
+							t.Fatalf("import: %v", err)
+						}
+						if !reflect.DeepEqual(report, want) {
+							t.Fatalf("expected report %+v, got %+v", want, report)
+						}
+					}
+					if err = mock.ExpectationsWereMet(); err != nil {
+						t.Fatalf("import queries: %v", err)
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestSnapshotImportRejectsUnknownPolicy проверяет, что неизвестная политика конфликтов
+// отклоняется до начала загрузки.
+func TestSnapshotImportRejectsUnknownPolicy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	service := NewSnapshotService(repository.NewSnapshotRepository(db))
+	_, err = service.Import(bytes.NewReader(nil), model.SnapshotJSON, model.ImportPolicy("merge"))
+	if !errors.Is(err, ErrInvalidImportPolicy) {
+		t.Fatalf("expected ErrInvalidImportPolicy, got %v", err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unexpected queries: %v", err)
+	}
+}
+
+// TestSnapshotRoundTripKeepsDependenciesAndReviewTeams проверяет, что зависимости PR и команды
+// ревью (вместе с approval_required) выгружаются и загружаются обратно с теми же значениями.
+func TestSnapshotRoundTripKeepsDependenciesAndReviewTeams(t *testing.T) {
+	fixture := snapshotFixture()
+	records := make(map[model.SnapshotSection][]interface{}, len(fixture))
+	for _, s := range fixture {
+		records[s.section] = s.records
+	}
+
+	for _, format := range []model.SnapshotFormat{model.SnapshotJSON, model.SnapshotCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock: %v", err)
+			}
+			defer db.Close()
+			expectSnapshotExport(t, mock, fixture)
+			// Значения каждой вставки сверяются с выгруженными через WithArgs
+			expectSnapshotImport(mock, fixture, model.ImportSkip, false)
+
+			service := NewSnapshotService(repository.NewSnapshotRepository(db))
+			var exported bytes.Buffer
+			if err = service.Export(&exported, format); err != nil {
+				t.Fatalf("export: %v", err)
+			}
+			report, err := service.Import(bytes.NewReader(exported.Bytes()), format, model.ImportSkip)
+			if err != nil {
+				t.Fatalf("import: %v", err)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("queries: %v", err)
+			}
+
+			for _, section := range []model.SnapshotSection{model.SectionDependencies, model.SectionReviewTeams} {
+				counts := report.Sections[section]
+				if counts == nil || counts.Inserted != len(records[section]) {
+					t.Fatalf("expected %d %s inserted, got %+v", len(records[section]), section, counts)
+				}
+			}
+		})
+	}
+}
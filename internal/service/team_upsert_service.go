@@ -0,0 +1,211 @@
+package service
+
+import (
+	"avito-assignment/internal/model"
+	"avito-assignment/internal/repository"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// UpsertTeam приводит команду к состоянию из запроса: создает команду, если ее нет,
+// создает недостающих пользователей, переводит в команду перечисленных и снимает с нее
+// остальных участников (в том числе неактивных). Все изменения, включая снятие ушедших
+// и деактивированных участников с открытых ревью, применяются в одной транзакции.
+// После фиксации PR команды доукомплектовываются новыми активными участниками.
+// Версия существующей команды должна быть равна expectedVersion, иначе возвращается
+// ErrVersionMismatch; с repository.AnyVersion версия не проверяется и команду можно создать.
+func (s *TeamService) UpsertTeam(req model.TeamUpsertRequest, expectedVersion int64, prService *PRService) (*model.TeamUpsertResult, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrTeamNameRequired
+	}
+
+	plan := &model.TeamUpsertPlan{}
+	diff := model.TeamUpsertDiff{
+		CreatedUsers:   []uuid.UUID{},
+		AddedMembers:   []model.MemberMove{},
+		RemovedMembers: []uuid.UUID{},
+		UpdatedUsers:   []uuid.UUID{},
+	}
+
+	existingTeam, err := s.teamRepo.GetByName(name)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if expectedVersion != repository.AnyVersion {
+			return nil, ErrVersionMismatch
+		}
+		plan.Team = model.Team{ID: uuid.New(), Name: name}
+		plan.CreateTeam = true
+		diff.TeamCreated = true
+	case err != nil:
+		return nil, err
+	case expectedVersion != repository.AnyVersion && existingTeam.Version != expectedVersion:
+		return nil, ErrVersionMismatch
+	default:
+		plan.Team = *existingTeam
+	}
+	teamID := plan.Team.ID
+
+	var current []model.User
+	if !plan.CreateTeam {
+		if current, err = s.teamRepo.GetMembers(teamID); err != nil {
+			return nil, err
+		}
+	}
+
+	var leaving []model.User
+	joined := false
+	listed := make(map[uuid.UUID]bool, len(req.Members))
+	usernames := make(map[string]bool, len(req.Members))
+
+	for i, m := range req.Members {
+		user, errResolve := s.resolveUpsertMember(i, m)
+		if errResolve != nil {
+			return nil, errResolve
+		}
+
+		if user == nil {
+			created := model.User{ID: uuid.New(), Username: m.Username, TeamID: teamID, IsActive: true}
+			if m.IsActive != nil {
+				created.IsActive = *m.IsActive
+			}
+			if m.IsSenior != nil {
+				created.IsSenior = *m.IsSenior
+			}
+			if usernames[created.Username] {
+				return nil, ErrDuplicateTeamMember.WithDetails(map[string]string{"username": created.Username})
+			}
+			usernames[created.Username] = true
+			plan.CreateUsers = append(plan.CreateUsers, created)
+			diff.CreatedUsers = append(diff.CreatedUsers, created.ID)
+			joined = joined || created.IsActive
+			continue
+		}
+
+		if listed[user.ID] {
+			return nil, ErrDuplicateTeamMember.WithDetails(map[string]string{"user_id": user.ID.String()})
+		}
+		listed[user.ID] = true
+
+		updated := *user
+		updated.TeamID = teamID
+		if m.UserID != nil && m.Username != "" {
+			updated.Username = m.Username
+		}
+		if m.IsActive != nil {
+			updated.IsActive = *m.IsActive
+		}
+		if m.IsSenior != nil {
+			updated.IsSenior = *m.IsSenior
+		}
+		if usernames[updated.Username] {
+			return nil, ErrDuplicateTeamMember.WithDetails(map[string]string{"username": updated.Username})
+		}
+		usernames[updated.Username] = true
+
+		if updated.Username != user.Username {
+			owner, errOwner := s.userRepo.GetUserByUsername(updated.Username)
+			if errOwner == nil && owner.ID != user.ID {
+				return nil, ErrUserExists.WithDetails(map[string]string{"username": updated.Username})
+			}
+			if errOwner != nil && !errors.Is(errOwner, sql.ErrNoRows) {
+				return nil, errOwner
+			}
+		}
+
+		if user.TeamID != teamID {
+			move := model.MemberMove{UserID: user.ID}
+			if user.TeamID != uuid.Nil {
+				fromTeamID := user.TeamID
+				move.FromTeamID = &fromTeamID
+			}
+			diff.AddedMembers = append(diff.AddedMembers, move)
+		}
+		if updated.Username != user.Username || updated.IsActive != user.IsActive || updated.IsSenior != user.IsSenior {
+			diff.UpdatedUsers = append(diff.UpdatedUsers, user.ID)
+		}
+		if updated != *user {
+			plan.UpdateUsers = append(plan.UpdateUsers, updated)
+		}
+
+		if user.IsActive && (!updated.IsActive || user.TeamID != teamID) {
+			leaving = append(leaving, *user)
+		}
+		joined = joined || (updated.IsActive && (!user.IsActive || user.TeamID != teamID))
+	}
+
+	for _, member := range current {
+		if listed[member.ID] {
+			continue
+		}
+		plan.RemoveUsers = append(plan.RemoveUsers, member)
+		diff.RemovedMembers = append(diff.RemovedMembers, member.ID)
+		if member.IsActive {
+			leaving = append(leaving, member)
+		}
+	}
+
+	result := &model.TeamUpsertResult{Diff: diff}
+	if len(leaving) > 0 {
+		result.Offboarding, err = prService.planOffboarding(leaving)
+		if err != nil {
+			return nil, err
+		}
+		plan.Offboarding = result.Offboarding.Replacements
+	}
+
+	if err = s.applyUpsertPlan(plan, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	if joined {
+		if _, err = prService.TopUpTeamReviews(teamID); err != nil {
+			log.Printf("failed to top up reviewers for team %s: %v", teamID, err)
+		}
+	}
+
+	result.Team, err = s.GetTeamByID(teamID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// resolveUpsertMember находит пользователя, на которого ссылается i-й участник запроса.
+// Возвращает nil, если пользователя с таким именем нет и его нужно создать.
+func (s *TeamService) resolveUpsertMember(i int, m model.TeamUpsertMember) (*model.User, error) {
+	switch {
+	case m.UserID != nil:
+		user, err := s.userRepo.GetUserByID(*m.UserID)
+		if err != nil {
+			return nil, ErrUserNotFound.WithDetails(map[string]string{"user_id": m.UserID.String()})
+		}
+		return user, nil
+	case strings.TrimSpace(m.Username) != "":
+		user, err := s.userRepo.GetUserByUsername(m.Username)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return user, err
+	default:
+		return nil, ErrTeamMemberRefRequired.WithDetails(map[string]int{"index": i})
+	}
+}
+
+// applyUpsertPlan применяет план upsert в транзакции. Если команду, ее участников
+// или их ревью изменили параллельно, возвращает ErrConcurrentUpdate: запрос можно повторить
+// (или ErrVersionMismatch, если клиент передал ожидаемую версию команды).
+func (s *TeamService) applyUpsertPlan(plan *model.TeamUpsertPlan, expectedVersion int64) error {
+	err := s.teamRepo.ApplyUpsert(plan)
+	switch {
+	case errors.Is(err, repository.ErrStaleVersion) && expectedVersion != repository.AnyVersion:
+		return ErrVersionMismatch
+	case errors.Is(err, repository.ErrStaleVersion) || isUniqueViolation(err):
+		return ErrConcurrentUpdate
+	}
+	return err
+}
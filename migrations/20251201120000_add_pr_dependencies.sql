@@ -0,0 +1,30 @@
+-- +goose Up
+
+-- Зависимости между PR (стеки): pr_id нельзя мержить, пока depends_on_id не MERGED
+CREATE TABLE pr_dependencies (
+                                 pr_id UUID NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+                                 depends_on_id UUID NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+                                 created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+                                 PRIMARY KEY (pr_id, depends_on_id),
+                                 CHECK (pr_id <> depends_on_id)
+);
+
+-- События по PR (например, разблокировка после мержа зависимости)
+CREATE TABLE pr_events (
+                           id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                           pr_id UUID NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+                           event_type TEXT NOT NULL,
+                           payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+                           created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_pr_dependencies_depends_on ON pr_dependencies(depends_on_id);
+CREATE INDEX idx_pr_events_pr ON pr_events(pr_id, created_at);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_pr_events_pr;
+DROP INDEX IF EXISTS idx_pr_dependencies_depends_on;
+
+DROP TABLE IF EXISTS pr_events;
+DROP TABLE IF EXISTS pr_dependencies;
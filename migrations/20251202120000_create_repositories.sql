@@ -0,0 +1,33 @@
+-- +goose Up
+
+-- Репозитории (кодовые базы), к которым относятся PR
+CREATE TABLE repositories (
+                              id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                              name TEXT NOT NULL UNIQUE,
+                              required_reviewers INT NOT NULL DEFAULT 2 CHECK (required_reviewers >= 0),
+                              assignment_strategy TEXT NOT NULL DEFAULT 'random',
+                              created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- Команды-владельцы репозитория (many-to-many)
+CREATE TABLE repository_teams (
+                                  repository_id UUID NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+                                  team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+                                  PRIMARY KEY (repository_id, team_id)
+);
+
+ALTER TABLE pull_requests
+    ADD COLUMN repository_id UUID NULL REFERENCES repositories(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_repository_teams_team ON repository_teams(team_id);
+CREATE INDEX idx_pr_repository ON pull_requests(repository_id);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_pr_repository;
+DROP INDEX IF EXISTS idx_repository_teams_team;
+
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS repository_id;
+
+DROP TABLE IF EXISTS repository_teams;
+DROP TABLE IF EXISTS repositories;
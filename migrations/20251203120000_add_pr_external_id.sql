@@ -0,0 +1,13 @@
+-- +goose Up
+
+-- Внешний идентификатор PR (например, репозиторий + номер PR в VCS) для идемпотентного создания
+ALTER TABLE pull_requests
+    ADD COLUMN external_id TEXT NULL;
+
+CREATE UNIQUE INDEX idx_pr_external_id ON pull_requests(external_id) WHERE external_id IS NOT NULL;
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_pr_external_id;
+
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS external_id;
@@ -0,0 +1,28 @@
+-- +goose Up
+
+-- Тип изменения состава ревьюверов: переназначение администратором или самоотвод ревьювера
+CREATE TYPE reviewer_change_kind AS ENUM ('REASSIGN','DECLINE');
+
+-- История изменений ревьюверов PR. Записи DECLINE также навсегда исключают
+-- ревьювера из кандидатов для этого PR.
+CREATE TABLE pr_reviewer_changes (
+                                     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                     pr_id UUID NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+                                     old_reviewer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                     new_reviewer_id UUID NULL REFERENCES users(id) ON DELETE SET NULL,
+                                     kind reviewer_change_kind NOT NULL,
+                                     reason TEXT NOT NULL DEFAULT '',
+                                     created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_pr_reviewer_changes_pr ON pr_reviewer_changes(pr_id, kind);
+CREATE INDEX idx_pr_reviewer_changes_old ON pr_reviewer_changes(old_reviewer_id);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_pr_reviewer_changes_old;
+DROP INDEX IF EXISTS idx_pr_reviewer_changes_pr;
+
+DROP TABLE IF EXISTS pr_reviewer_changes;
+
+DROP TYPE IF EXISTS reviewer_change_kind;
@@ -0,0 +1,31 @@
+-- +goose Up
+
+-- Сколько ревьюверов требуется PR (фиксируется при создании из настроек репозитория)
+ALTER TABLE pull_requests
+    ADD COLUMN required_reviewers INT NOT NULL DEFAULT 2 CHECK (required_reviewers >= 0);
+
+-- Команды, из которых набираются ревьюверы PR (очередь неназначенных ревью строится по ним)
+CREATE TABLE pr_review_teams (
+                                 pr_id UUID NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+                                 team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+                                 PRIMARY KEY (pr_id, team_id)
+);
+
+CREATE INDEX idx_pr_review_teams_team ON pr_review_teams(team_id);
+CREATE INDEX idx_pr_status ON pull_requests(status);
+
+-- Для существующих PR ревьюверы набирались из команды автора
+INSERT INTO pr_review_teams (pr_id, team_id)
+SELECT pr.id, u.team_id
+FROM pull_requests pr
+JOIN users u ON u.id = pr.author_id
+WHERE u.team_id IS NOT NULL;
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_pr_status;
+DROP INDEX IF EXISTS idx_pr_review_teams_team;
+
+DROP TABLE IF EXISTS pr_review_teams;
+
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS required_reviewers;
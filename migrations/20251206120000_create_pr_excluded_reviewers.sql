@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- Пользователи, которых автор запретил назначать ревьюверами PR
+CREATE TABLE pr_excluded_reviewers (
+                                       pr_id UUID NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+                                       user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                       PRIMARY KEY (pr_id, user_id)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS pr_excluded_reviewers;
@@ -0,0 +1,17 @@
+-- +goose Up
+
+-- Роль ревьювера: обязательные учитываются в политике мержа, опциональные — только для сведения
+CREATE TYPE reviewer_role AS ENUM ('REQUIRED','OPTIONAL');
+
+ALTER TABLE pr_reviewers
+    ADD COLUMN role reviewer_role NOT NULL DEFAULT 'REQUIRED';
+
+CREATE INDEX idx_pr_reviewers_pr_role ON pr_reviewers(pr_id, role);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_pr_reviewers_pr_role;
+
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS role;
+
+DROP TYPE IF EXISTS reviewer_role;
@@ -0,0 +1,24 @@
+-- +goose Up
+
+-- Теневой ревьювер (наставничество): джуниор видит ревью, но ничего не блокирует
+ALTER TYPE reviewer_role ADD VALUE IF NOT EXISTS 'SHADOW';
+
+ALTER TABLE teams
+    ADD COLUMN shadowing_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Джуниоры команды, которые по очереди назначаются теневыми ревьюверами
+CREATE TABLE team_shadow_reviewers (
+                                       team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+                                       user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                       last_assigned_at TIMESTAMP WITH TIME ZONE NULL,
+                                       PRIMARY KEY (team_id, user_id)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS team_shadow_reviewers;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS shadowing_enabled;
+
+-- Значение 'SHADOW' нельзя удалить из enum, поэтому удаляем только сами назначения
+DELETE FROM pr_reviewers WHERE role::text = 'SHADOW';
@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- Правила конфликта интересов: reviewer_id никогда не ревьюит PR автора author_id.
+-- Для mutual = true запрет действует в обе стороны (пара пользователей).
+CREATE TABLE reviewer_conflicts (
+                                    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                    reviewer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                    mutual BOOLEAN NOT NULL DEFAULT TRUE,
+                                    reason TEXT NOT NULL DEFAULT '',
+                                    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+                                    UNIQUE (reviewer_id, author_id),
+                                    CHECK (reviewer_id <> author_id)
+);
+
+CREATE INDEX idx_reviewer_conflicts_author ON reviewer_conflicts(author_id);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_reviewer_conflicts_author;
+
+DROP TABLE IF EXISTS reviewer_conflicts;
@@ -0,0 +1,37 @@
+-- +goose Up
+
+-- Размер и риск PR (для выбора числа ревьюверов и статистики времени до мержа)
+ALTER TABLE pull_requests
+    ADD COLUMN lines_added INT NOT NULL DEFAULT 0 CHECK (lines_added >= 0),
+    ADD COLUMN lines_removed INT NOT NULL DEFAULT 0 CHECK (lines_removed >= 0),
+    ADD COLUMN files_changed INT NOT NULL DEFAULT 0 CHECK (files_changed >= 0),
+    ADD COLUMN high_risk BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Старшие (senior) разработчики, которых требуют крупные или рискованные PR
+ALTER TABLE users
+    ADD COLUMN is_senior BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Уровни команды: PR размером от min_lines строк (добавлено + удалено) получает
+-- reviewers ревьюверов, из них не меньше senior_reviewers старших.
+-- Уровни с high_risk = true применяются только к рискованным PR.
+CREATE TABLE team_reviewer_tiers (
+                                     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                     team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+                                     min_lines INT NOT NULL DEFAULT 0 CHECK (min_lines >= 0),
+                                     high_risk BOOLEAN NOT NULL DEFAULT FALSE,
+                                     reviewers INT NOT NULL CHECK (reviewers >= 0),
+                                     senior_reviewers INT NOT NULL DEFAULT 0 CHECK (senior_reviewers >= 0 AND senior_reviewers <= reviewers),
+                                     UNIQUE (team_id, min_lines, high_risk)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS team_reviewer_tiers;
+
+ALTER TABLE users DROP COLUMN IF EXISTS is_senior;
+
+ALTER TABLE pull_requests
+    DROP COLUMN IF EXISTS high_risk,
+    DROP COLUMN IF EXISTS files_changed,
+    DROP COLUMN IF EXISTS lines_removed,
+    DROP COLUMN IF EXISTS lines_added;
@@ -0,0 +1,35 @@
+-- +goose Up
+
+-- Решение ревьювера по текущей ревизии PR
+CREATE TYPE review_decision AS ENUM ('PENDING','APPROVED');
+
+ALTER TABLE pr_reviewers
+    ADD COLUMN decision review_decision NOT NULL DEFAULT 'PENDING',
+    ADD COLUMN decided_at TIMESTAMP WITH TIME ZONE;
+
+-- Политика команды: сбрасывать ли одобрения при новой ревизии PR
+ALTER TABLE teams
+    ADD COLUMN reset_approvals_on_revision BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Ревизии PR (новые пуши). Создание PR — ревизия 1, первая запись получает номер 2.
+CREATE TABLE pr_revisions (
+                              id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                              pr_id UUID NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+                              number INT NOT NULL,
+                              commit_sha VARCHAR(64),
+                              approvals_reset BOOLEAN NOT NULL DEFAULT FALSE,
+                              created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                              UNIQUE (pr_id, number)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS pr_revisions;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS reset_approvals_on_revision;
+
+ALTER TABLE pr_reviewers
+    DROP COLUMN IF EXISTS decided_at,
+    DROP COLUMN IF EXISTS decision;
+
+DROP TYPE IF EXISTS review_decision;
@@ -0,0 +1,9 @@
+-- +goose Up
+
+-- Целевые команды кросс-командного PR: для мержа нужно одобрение от каждой из них
+ALTER TABLE pr_review_teams
+    ADD COLUMN approval_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+
+ALTER TABLE pr_review_teams DROP COLUMN IF EXISTS approval_required;
@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- Приоритет PR и срок ревью по SLA (учитываются в личной очереди ревью)
+CREATE TYPE pr_priority AS ENUM ('LOW','NORMAL','HIGH','CRITICAL');
+
+ALTER TABLE pull_requests
+    ADD COLUMN priority pr_priority NOT NULL DEFAULT 'NORMAL',
+    ADD COLUMN review_due_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_pr_reviewers_reviewer_decision ON pr_reviewers(reviewer_id, decision);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_pr_reviewers_reviewer_decision;
+
+ALTER TABLE pull_requests
+    DROP COLUMN IF EXISTS review_due_at,
+    DROP COLUMN IF EXISTS priority;
+
+DROP TYPE IF EXISTS pr_priority;
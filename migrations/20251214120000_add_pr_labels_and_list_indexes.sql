@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- Метки PR и индексы для фильтрации, сортировки и постраничной выдачи списка PR
+ALTER TABLE pull_requests
+    ADD COLUMN labels TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_pr_labels ON pull_requests USING GIN (labels);
+CREATE INDEX idx_pr_created_at_id ON pull_requests(created_at, id);
+CREATE INDEX idx_pr_priority_id ON pull_requests(priority, id);
+CREATE INDEX idx_pr_name_id ON pull_requests(pull_request_name, id);
+CREATE INDEX idx_pr_merged_at ON pull_requests(merged_at) WHERE merged_at IS NOT NULL;
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_pr_merged_at;
+DROP INDEX IF EXISTS idx_pr_name_id;
+DROP INDEX IF EXISTS idx_pr_priority_id;
+DROP INDEX IF EXISTS idx_pr_created_at_id;
+DROP INDEX IF EXISTS idx_pr_labels;
+
+ALTER TABLE pull_requests
+    DROP COLUMN IF EXISTS labels;
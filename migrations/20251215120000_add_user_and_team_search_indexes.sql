@@ -0,0 +1,10 @@
+-- +goose Up
+
+-- Индексы для поиска пользователей и команд по началу имени без учета регистра
+CREATE INDEX idx_users_username_lower ON users(lower(username) text_pattern_ops);
+CREATE INDEX idx_teams_name_lower ON teams(lower(name) text_pattern_ops);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_teams_name_lower;
+DROP INDEX IF EXISTS idx_users_username_lower;
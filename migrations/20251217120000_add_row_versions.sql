@@ -0,0 +1,36 @@
+-- +goose Up
+
+-- Версии строк для оптимистичных блокировок (ETag / If-Match).
+-- Триггер увеличивает версию при каждом изменении строки, если запрос не сделал этого сам.
+ALTER TABLE users ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE teams ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE pull_requests ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+-- +goose StatementBegin
+CREATE FUNCTION bump_row_version() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.version = OLD.version THEN
+        NEW.version := OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER users_bump_version BEFORE UPDATE ON users
+    FOR EACH ROW EXECUTE FUNCTION bump_row_version();
+CREATE TRIGGER teams_bump_version BEFORE UPDATE ON teams
+    FOR EACH ROW EXECUTE FUNCTION bump_row_version();
+CREATE TRIGGER pull_requests_bump_version BEFORE UPDATE ON pull_requests
+    FOR EACH ROW EXECUTE FUNCTION bump_row_version();
+
+-- +goose Down
+
+DROP TRIGGER IF EXISTS pull_requests_bump_version ON pull_requests;
+DROP TRIGGER IF EXISTS teams_bump_version ON teams;
+DROP TRIGGER IF EXISTS users_bump_version ON users;
+DROP FUNCTION IF EXISTS bump_row_version();
+
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS version;
+ALTER TABLE teams DROP COLUMN IF EXISTS version;
+ALTER TABLE users DROP COLUMN IF EXISTS version;
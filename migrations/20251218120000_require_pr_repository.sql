@@ -0,0 +1,27 @@
+-- +goose Up
+
+-- PR, созданные до появления репозиториев, переносятся в репозиторий по умолчанию
+-- с прежними настройками назначения (2 ревьювера, случайная стратегия, без владельцев)
+INSERT INTO repositories (name)
+SELECT 'default'
+WHERE EXISTS (SELECT 1 FROM pull_requests WHERE repository_id IS NULL)
+ON CONFLICT (name) DO NOTHING;
+
+UPDATE pull_requests
+SET repository_id = (SELECT id FROM repositories WHERE name = 'default')
+WHERE repository_id IS NULL;
+
+-- Каждый PR относится к репозиторию; репозиторий с PR удалить нельзя
+ALTER TABLE pull_requests
+    DROP CONSTRAINT pull_requests_repository_id_fkey,
+    ADD CONSTRAINT pull_requests_repository_id_fkey
+        FOREIGN KEY (repository_id) REFERENCES repositories(id) ON DELETE RESTRICT,
+    ALTER COLUMN repository_id SET NOT NULL;
+
+-- +goose Down
+
+ALTER TABLE pull_requests
+    ALTER COLUMN repository_id DROP NOT NULL,
+    DROP CONSTRAINT pull_requests_repository_id_fkey,
+    ADD CONSTRAINT pull_requests_repository_id_fkey
+        FOREIGN KEY (repository_id) REFERENCES repositories(id) ON DELETE SET NULL;
@@ -0,0 +1,18 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+
+-- Замены ревьюверов при деактивации или переходе в другую команду учитываются
+-- отдельно от переназначений администратором. Новое значение enum нельзя использовать
+-- в транзакции, которая его добавила, поэтому миграция выполняется без транзакции.
+ALTER TYPE reviewer_change_kind ADD VALUE IF NOT EXISTS 'OFFBOARD';
+
+UPDATE pr_reviewer_changes
+SET kind = 'OFFBOARD'
+WHERE kind = 'REASSIGN' AND reason = 'offboarding';
+
+-- +goose Down
+
+-- Значение enum удалить нельзя, записи возвращаются к прежнему типу
+UPDATE pr_reviewer_changes
+SET kind = 'REASSIGN'
+WHERE kind = 'OFFBOARD';